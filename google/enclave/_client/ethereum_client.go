@@ -3,157 +3,126 @@ package client
 import (
 	"context"
 	"fmt"
-	"math/big"
-	"os"
 	"time"
 
-	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/EkamSinghPandher/Tee-Google/securelib"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func SubmitAttestationToBlockchain(attestation []byte) error {
-	client := network.GetEthereumClient()
-	if client == nil {
-		return fmt.Errorf("ethereum client not initialized")
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Get chain ID
-	chainID, err := client.ChainID(ctx)
+// NewSubmissionQueue verifies the chain against the profile and opens its submission queue, signing with the
+// profile's key. Submissions left unfinished by a previous run are resumed in the background.
+func NewSubmissionQueue(ctx context.Context, client *ethclient.Client, profile *ChainProfile) (*SubmissionQueue, error) {
+	// Get chain ID and check it is the chain the profile was written for
+	chainID, err := profile.Verify(ctx, client)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %v", err)
+		return nil, err
 	}
 	log.Infof("Connected to chain ID: %s", chainID.String())
 
-	// Get latest block to verify connection
-	block, err := client.BlockNumber(ctx)
+	registry, err := NewRegistry(client, profile.RegistryAddress)
 	if err != nil {
-		return fmt.Errorf("failed to get block number: %v", err)
-	}
-	log.Infof("Latest block: %d", block)
-
-	submitToDKIMOracle(client, attestation)
-
-	return nil
-}
-
-func submitToDKIMOracle(client *ethclient.Client, attestation []byte) error {
-	log.Infof("Submitting %d bytes of attestation data to blockchain", len(attestation))
-
-	contractAddress := common.HexToAddress("0xCf7Ed3AccA5a467e9e704C703E8D87F634fB0Fc9") // DKIMOracle contract address (deployed with split architecture)
-
-	// Get private key from environment (same as deploy script)
-	privateKeyHex := os.Getenv("PRIVATE_KEY")
-	if privateKeyHex == "" {
-		// Fallback to Anvil test key for development
-		privateKeyHex = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
-		log.Warn("No PRIVATE_KEY env var set, using default Anvil key")
+		return nil, err
 	}
 
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	auth, err := profile.transactor(ctx, chainID)
 	if err != nil {
-		return fmt.Errorf("invalid private key: %v", err)
+		return nil, err
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(31337))
+	queue, err := OpenSubmissionQueue(ctx, client, profile, registry, auth)
 	if err != nil {
-		return fmt.Errorf("failed to create transactor: %v", err)
-	}
-
-	// Set gas limit to 60M gas (should use alittle over 55M for first time validation)
-	gas_limit := uint64(60000000)
-	auth.GasLimit = gas_limit
-
-	log.Infof("Calling DKIMOracle contract at %s with attestation: %d bytes", contractAddress.Hex(), len(attestation))
-	log.Infof("Using account address: %s", crypto.PubkeyToAddress(privateKey.PublicKey).Hex())
-
-	methodSig := crypto.Keccak256([]byte("decodeAndValidateAttestation(bytes)"))[:4]
-
-	// ABI encode the attestation data
-	// For bytes parameter: offset(32) + length(32) + data + padding
-	offset := make([]byte, 32)
-	big.NewInt(32).FillBytes(offset) // Offset to data start (32 bytes)
-
-	length := make([]byte, 32)
-	big.NewInt(int64(len(attestation))).FillBytes(length)
-
-	// Calculate padding needed to make attestation data 32-byte aligned
-	padding := 32 - (len(attestation) % 32)
-	if padding == 32 {
-		padding = 0
+		return nil, err
 	}
 
-	// Build call data: methodSig + offset + length + data + padding
-	callData := append(methodSig, offset...)
-	callData = append(callData, length...)
-	callData = append(callData, attestation...)
-	callData = append(callData, make([]byte, padding)...)
-
-	log.Infof("Sending transaction with %d bytes of call data...", len(callData))
-
-	// Send transaction
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	go queue.Recover()
+	return queue, nil
+}
 
-	// Get nonce
-	fromAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
-	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+// SubmitAttestationToBlockchain stores the keys in the attestation through the registry of every target. The
+// attestation is checked once against the chain it is anchored to, which must be one of the targets, and then
+// submitted to each target independently. Targets that accepted it are bound to the enclave key in the attestation.
+// A failure on some targets is returned as a *PartialFailureError next to the result of every target.
+func SubmitAttestationToBlockchain(ctx context.Context, targets []*ChainTarget, attestation []byte) (*FanOutResult, error) {
+	err := checkAttestationFreshness(ctx, targets, attestation, freshnessWindow)
 	if err != nil {
-		return fmt.Errorf("failed to get nonce: %v", err)
+		return nil, fmt.Errorf("refusing to submit stale attestation: %v", err)
 	}
 
-	// Get gas price
-	gasPrice, err := client.SuggestGasPrice(ctx)
+	fields, err := securelib.ReadAttestationFields(attestation)
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		return nil, err
+	}
+	userData, _ := fields["user_data"].([]byte)
+
+	var enclaveKey common.Address
+	if pubKey, ok := fields["public_key"].([]byte); ok {
+		key, err := crypto.UnmarshalPubkey(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attested public key: %v", err)
+		}
+		enclaveKey = crypto.PubkeyToAddress(*key)
 	}
 
-	// Create transaction
-	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    nonce,
-		To:       &contractAddress,
-		Value:    big.NewInt(0),
-		Gas:      gas_limit,
-		GasPrice: gasPrice,
-		Data:     callData,
+	result := fanOut(ctx, KindAttestation, targets, func(ctx context.Context, target *ChainTarget) (*SubmissionOutcome, error) {
+		log.Infof("Calling DKIMRegistry contract on %s at %s (oracle %s) with attestation: %d bytes",
+			target.Name(), target.Registry.Address.Hex(), target.Registry.OracleAddress.Hex(), len(attestation))
+
+		outcome, err := target.Submitter.Submit(ctx, KindAttestation, attestation)
+		if err != nil {
+			return outcome, err
+		}
+		if enclaveKey != (common.Address{}) {
+			target.setBoundKey(enclaveKey)
+		}
+
+		keys, err := target.Registry.GetAllDKIMKeys(ctx)
+		if err != nil {
+			return outcome, err
+		}
+		log.Infof("✅ Registry on %s now holds %d DKIM keys", target.Name(), len(keys))
+
+		return outcome, verifyReadBack(ctx, target, outcome, userData)
 	})
+	return result, result.Err()
+}
 
-	// Sign transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(31337)), privateKey)
-	if err != nil {
-		return fmt.Errorf("failed to sign transaction: %v", err)
+// SubmitSignedUpdateToBlockchain publishes a key set signed by the enclave key to every target. The targets must
+// support signed updates and have accepted an attestation for the key. This skips the attestation validation and only
+// costs a signature check on chain.
+func SubmitSignedUpdateToBlockchain(ctx context.Context, targets []*ChainTarget, update *identity.SignedUpdate) (*FanOutResult, error) {
+	for _, target := range targets {
+		if !target.SignedUpdates {
+			return nil, fmt.Errorf("registry on %s does not accept signed updates", target.Name())
+		}
 	}
-
-	// Send transaction
-	err = client.SendTransaction(ctx, signedTx)
+	encoded, err := update.Encode()
 	if err != nil {
-		return fmt.Errorf("failed to send transaction: %v", err)
+		return nil, err
 	}
 
-	log.Infof("Transaction sent! Hash: %s", signedTx.Hash().Hex())
-	log.Infof("Waiting for transaction confirmation...")
+	result := fanOut(ctx, KindSignedUpdate, targets, func(ctx context.Context, target *ChainTarget) (*SubmissionOutcome, error) {
+		log.Infof("Calling DKIMRegistry contract on %s at %s with signed update: %s",
+			target.Name(), target.Registry.Address.Hex(), update)
 
-	// Wait for transaction receipt
-	receipt, err := bind.WaitMined(ctx, client, signedTx)
-	if err != nil {
-		return fmt.Errorf("transaction failed: %v", err)
-	}
+		// Signature verification and storage only, no certificate chain to validate
+		outcome, err := target.Submitter.Submit(ctx, KindSignedUpdate, encoded)
+		if err != nil {
+			return outcome, err
+		}
 
-	if receipt.Status == 1 {
-		log.Infof("✅ Transaction successful! Block: %d, Gas used: %d", receipt.BlockNumber.Uint64(), receipt.GasUsed)
-		log.Infof("✅ Attestation validated and processed on-chain!")
-	} else {
-		log.Errorf("❌ Transaction receipt: %v", receipt)
-		return fmt.Errorf("transaction execution failed")
-	}
+		return outcome, verifyReadBack(ctx, target, outcome, update.Payload)
+	})
+	return result, result.Err()
+}
 
-	return nil
+// verifyReadBack reads the submitted keys back from the target's registry once the submission is confirmed.
+func verifyReadBack(ctx context.Context, target *ChainTarget, outcome *SubmissionOutcome, userData []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return target.Registry.VerifyReadBack(ctx, target.Client, outcome.TxHash, userData)
 }
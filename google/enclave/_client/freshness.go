@@ -0,0 +1,89 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/EkamSinghPandher/Tee-Google/securelib"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FreshnessWindow is how old an attestation may be before the client refuses to submit it.
+type FreshnessWindow struct {
+	MaxAge    time.Duration // since the oldest attested key was fetched
+	MaxBlocks uint64        // since the block the attestation is anchored to
+}
+
+var freshnessWindow = FreshnessWindow{
+	MaxAge:    config.DefaultMaxAttestationAge,
+	MaxBlocks: config.DefaultMaxAttestationBlocks,
+}
+
+// SetFreshnessWindow replaces the default freshness window.
+func SetFreshnessWindow(window FreshnessWindow) {
+	freshnessWindow = window
+}
+
+// checkAttestationFreshness rejects attestations without a freshness nonce, anchored to a block that is not on the
+// canonical chain, or older than the window. The anchor is checked on the target of the chain the nonce names.
+func checkAttestationFreshness(ctx context.Context, targets []*ChainTarget, attestation []byte, window FreshnessWindow) error {
+	fields, err := securelib.ReadAttestationFields(attestation)
+	if err != nil {
+		return fmt.Errorf("failed to read attestation: %v", err)
+	}
+
+	rawNonce, ok := fields["nonce"].([]byte)
+	if !ok || len(rawNonce) == 0 {
+		return fmt.Errorf("attestation has no freshness nonce")
+	}
+
+	nonce, err := schema.DecodeNonce(rawNonce)
+	if err != nil {
+		return err
+	}
+
+	age := time.Since(time.Unix(int64(nonce.FetchedAt), 0))
+	if age < 0 {
+		return fmt.Errorf("attested keys were fetched %s in the future", (-age).Round(time.Second))
+	}
+	if age > window.MaxAge {
+		return fmt.Errorf("attested keys were fetched %s ago, older than the %s window", age.Round(time.Second), window.MaxAge)
+	}
+
+	var client *ethclient.Client
+	for _, target := range targets {
+		if target.ChainID.Uint64() == nonce.ChainID {
+			client = target.Client
+		}
+	}
+	if client == nil {
+		return fmt.Errorf("attestation is anchored to chain %d, which is not a submission target", nonce.ChainID)
+	}
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %v", err)
+	}
+	if head > nonce.BlockNumber && head-nonce.BlockNumber > window.MaxBlocks {
+		return fmt.Errorf("attestation is anchored %d blocks behind head, more than %d", head-nonce.BlockNumber, window.MaxBlocks)
+	}
+
+	anchor, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(nonce.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get anchor block %d: %v", nonce.BlockNumber, err)
+	}
+	if !bytes.Equal(anchor.Hash().Bytes(), nonce.BlockHash) {
+		return fmt.Errorf("anchor block %d is no longer canonical", nonce.BlockNumber)
+	}
+
+	log.Infof("Attestation is fresh: keys fetched %s ago, anchored at block %d (head %d)",
+		age.Round(time.Second), nonce.BlockNumber, head)
+	return nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallerNonceFailsFreshness(t *testing.T) {
+	payload := &attest.AttestationPayload{
+		Provider: "google",
+		DKIMKeys: map[string]map[string]string{"gmail.com": {"20230601": "AQID"}},
+	}
+	// What a host process would hand control_attest to pass an old payload off as fresh
+	forged, err := schema.EncodeNonce(&schema.FreshnessNonce{
+		Version:     schema.NonceVersion,
+		ChainID:     31337,
+		BlockNumber: 100,
+		BlockHash:   make([]byte, 32),
+		BlockTime:   uint64(time.Now().Unix()),
+		FetchedAt:   uint64(time.Now().Unix()),
+	})
+	assert.NoError(t, err)
+
+	// Signed as it is, it would get past decoding, up to the anchor checks
+	document, err := attest.GenerateMockDKIMCBORAttestation(payload, nil, forged)
+	assert.NoError(t, err)
+	err = checkAttestationFreshness(context.Background(), nil, document, freshnessWindow)
+	assert.EqualError(t, err, "attestation is anchored to chain 31337, which is not a submission target")
+
+	// control_attest wraps it, which is never taken for a freshness nonce
+	wrapped, err := schema.EncodeCallerNonce(forged)
+	assert.NoError(t, err)
+	document, err = attest.GenerateMockDKIMCBORAttestation(payload, nil, wrapped)
+	assert.NoError(t, err)
+	err = checkAttestationFreshness(context.Background(), nil, document, freshnessWindow)
+	assert.ErrorContains(t, err, "failed to decode nonce")
+}
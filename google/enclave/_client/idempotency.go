@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyDiff classifies the attested DKIM keys, as domain;selector, against what the registry holds. The registry only
+// stores a key for a domain and selector it has none for, so a Stale key cannot be updated by any submission.
+type KeyDiff struct {
+	Added     []string
+	Stale     []string // held with another key, which the registry never overwrites
+	Unchanged []string
+}
+
+// SubmissionDecision says whether a key set is worth submitting.
+type SubmissionDecision struct {
+	Submit bool
+	Reason string
+	Diff   *KeyDiff
+}
+
+// DiffDKIMKeys compares the attested records with the registry by key hash. The registry stores the base64 text
+// from the flattened payload, it is decoded so only the DER key itself is compared.
+func (r *Registry) DiffDKIMKeys(ctx context.Context, records []schema.DKIMRecord) (*KeyDiff, error) {
+	diff := &KeyDiff{}
+	for _, record := range records {
+		name := record.Domain + ";" + record.Selector
+
+		stored, found, err := r.GetDKIMKey(ctx, record.Domain, record.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+
+		storedDER, err := base64.StdEncoding.DecodeString(string(stored))
+		if err != nil {
+			storedDER = stored
+		}
+		if crypto.Keccak256Hash(storedDER) == crypto.Keccak256Hash(record.PublicKey) {
+			diff.Unchanged = append(diff.Unchanged, name)
+		} else {
+			diff.Stale = append(diff.Stale, name)
+		}
+	}
+	return diff, nil
+}
+
+// DecideSubmission checks the payload against the target's registry and only asks for a submission when a DKIM key
+// was added. Stale keys are alerted on but never submitted, the transaction would pay for a full validation and store
+// nothing. JWKS keys are not stored by the registry yet, so they do not count towards a change.
+func (t *ChainTarget) DecideSubmission(ctx context.Context, payload *schema.PayloadV2) (*SubmissionDecision, error) {
+	var records []schema.DKIMRecord
+	if payload.DKIM != nil {
+		records = payload.DKIM.Records
+	}
+
+	diff, err := t.Registry.DiffDKIMKeys(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &SubmissionDecision{Diff: diff}
+	switch {
+	case len(records) == 0:
+		decision.Reason = "no DKIM keys in payload"
+	case len(diff.Added) == 0 && len(diff.Stale) > 0:
+		decision.Reason = "registry cannot update the stale keys"
+	case len(diff.Added) == 0:
+		decision.Reason = "registry already holds every key"
+	default:
+		decision.Submit = true
+		decision.Reason = "keys added"
+	}
+
+	metrics.StaleKeys.WithLabelValues(t.Name()).Set(float64(len(diff.Stale)))
+	if len(diff.Stale) > 0 {
+		log.WithFields(log.Fields{
+			"severity":   "alert",
+			"chain":      t.Name(),
+			"registry":   t.Registry.Address.Hex(),
+			"stale_keys": diff.Stale,
+		}).Errorf("🚨 Registry holds older keys it will not overwrite, verifiers on this chain use them")
+	}
+
+	fields := log.Fields{
+		"decision":  "skip",
+		"reason":    decision.Reason,
+		"chain":     t.Name(),
+		"registry":  t.Registry.Address.Hex(),
+		"added":     len(diff.Added),
+		"stale":     len(diff.Stale),
+		"unchanged": len(diff.Unchanged),
+	}
+	if decision.Submit {
+		fields["decision"] = "submit"
+		fields["added_keys"] = diff.Added
+		log.WithFields(fields).Infof("Submitting key set")
+	} else {
+		log.WithFields(fields).Infof("Skipping submission, nothing changed")
+	}
+	return decision, nil
+}
+
+// TargetsToSubmit decides for every chain target on its own whether the payload is worth submitting there. A target
+// whose registry cannot be compared is submitted to anyway.
+func TargetsToSubmit(ctx context.Context, payload *schema.PayloadV2) []*ChainTarget {
+	var targets []*ChainTarget
+	for _, target := range ChainTargets() {
+		decision, err := target.DecideSubmission(ctx, payload)
+		if err != nil {
+			log.Warnf("Error comparing keys with the registry on %s, submitting anyway: %v", target.Name(), err)
+			targets = append(targets, target)
+			continue
+		}
+		if decision.Submit {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NonceSource tells the nonce an account's next transaction needs, counting the ones in the mempool.
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out nonces for one account locally, so submissions do not collide by all asking the node for
+// the same pending nonce.
+type NonceManager struct {
+	mu      sync.Mutex
+	client  NonceSource
+	account common.Address
+
+	synced   bool
+	next     uint64
+	released []uint64               // reserved but never broadcast, handed out again before next
+	inFlight map[uint64]common.Hash // nonce -> latest broadcast attempt
+}
+
+func NewNonceManager(client NonceSource, account common.Address) *NonceManager {
+	return &NonceManager{
+		client:   client,
+		account:  account,
+		inFlight: make(map[uint64]common.Hash),
+	}
+}
+
+// Sync reconciles with the chain: the next nonce is the node's pending nonce or past the highest in-flight nonce,
+// whichever is higher, and released nonces the chain has moved past are dropped.
+func (m *NonceManager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sync(ctx)
+}
+
+func (m *NonceManager) sync(ctx context.Context) error {
+	pending, err := m.client.PendingNonceAt(ctx, m.account)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	next := pending
+	for nonce := range m.inFlight {
+		if nonce >= next {
+			next = nonce + 1
+		}
+	}
+	if m.synced && m.next > next {
+		// Nonces we reserved but the node has not seen yet
+		next = m.next
+	}
+
+	released := m.released[:0]
+	for _, nonce := range m.released {
+		if nonce >= pending && nonce < next {
+			released = append(released, nonce)
+		}
+	}
+
+	if m.synced && next != m.next {
+		log.Infof("Nonce manager for %s resynced: next nonce %d -> %d", m.account.Hex(), m.next, next)
+	}
+	m.released = released
+	m.next = next
+	m.synced = true
+	return nil
+}
+
+// Reserve hands out the lowest free nonce. It must be followed by Track once the transaction is broadcast, or by
+// Release if it never is.
+func (m *NonceManager) Reserve(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(m.released) > 0 {
+		nonce := m.released[0]
+		m.released = m.released[1:]
+		return nonce, nil
+	}
+
+	nonce := m.next
+	m.next++
+	return nonce, nil
+}
+
+// Track records the latest broadcast attempt at nonce, e.g. when recovering submissions after a restart.
+func (m *NonceManager) Track(nonce uint64, hash common.Hash) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[nonce] = hash
+	if m.synced && nonce >= m.next {
+		m.next = nonce + 1
+	}
+}
+
+// Release gives back a nonce that was never broadcast.
+func (m *NonceManager) Release(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.inFlight, nonce)
+	if nonce+1 == m.next {
+		m.next--
+		return
+	}
+	m.released = append(m.released, nonce)
+	sort.Slice(m.released, func(i, j int) bool { return m.released[i] < m.released[j] })
+}
+
+// Done marks the nonce as used on chain.
+func (m *NonceManager) Done(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, nonce)
+}
+
+// InFlight returns the broadcast nonces that are not done yet.
+func (m *NonceManager) InFlight() map[uint64]common.Hash {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inFlight := make(map[uint64]common.Hash, len(m.inFlight))
+	for nonce, hash := range m.inFlight {
+		inFlight[nonce] = hash
+	}
+	return inFlight
+}
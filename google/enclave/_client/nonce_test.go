@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func reserve(t *testing.T, m *NonceManager) uint64 {
+	nonce, err := m.Reserve(context.Background())
+	assert.NoError(t, err)
+	return nonce
+}
+
+func TestNonceManagerReusesReleasedNonces(t *testing.T) {
+	backend := newFakeBackend(100, 5)
+	m := NewNonceManager(backend, common.HexToAddress("0x01"))
+
+	assert.Equal(t, uint64(5), reserve(t, m))
+	assert.Equal(t, uint64(6), reserve(t, m))
+	assert.Equal(t, uint64(7), reserve(t, m))
+
+	// A gap left by a submission that was never broadcast is filled first
+	m.Release(6)
+	assert.Equal(t, uint64(6), reserve(t, m))
+	assert.Equal(t, uint64(8), reserve(t, m))
+
+	// The highest nonce is simply handed out again
+	m.Release(8)
+	assert.Equal(t, uint64(8), reserve(t, m))
+}
+
+func TestNonceManagerSync(t *testing.T) {
+	backend := newFakeBackend(100, 5)
+	m := NewNonceManager(backend, common.HexToAddress("0x01"))
+
+	// A submission broadcast before a restart at a nonce the node has not seen yet: new nonces go past it, so they
+	// never collide with it when it is resumed
+	m.Track(9, common.HexToHash("0x09"))
+	assert.NoError(t, m.Sync(context.Background()))
+	assert.Equal(t, uint64(10), reserve(t, m))
+	assert.Equal(t, uint64(11), reserve(t, m))
+	assert.Equal(t, map[uint64]common.Hash{9: common.HexToHash("0x09")}, m.InFlight())
+
+	// A released nonce the chain moved past in the meantime is dropped
+	m.Release(10)
+	backend.pending = 11
+	assert.NoError(t, m.Sync(context.Background()))
+	assert.Equal(t, uint64(12), reserve(t, m))
+
+	m.Done(9)
+	assert.Empty(t, m.InFlight())
+}
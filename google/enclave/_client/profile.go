@@ -0,0 +1,418 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Well known Anvil/Hardhat account 0. Anyone can sign with it, so it is only ever acceptable on a dev chain.
+const defaultDevPrivateKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// Chain IDs used by local dev nodes (Anvil/Hardhat and Ganache/geth --dev).
+var devChainIDs = map[uint64]bool{31337: true, 1337: true}
+
+type GasPolicy struct {
+	AttestationGasLimit  uint64 // full attestation validation, a little over 55M the first time
+	SignedUpdateGasLimit uint64 // signature check and storage only
+
+	MaxPriorityFeePerGas *big.Int      // tip cap, nil uses the node's suggestion
+	MaxFeePerGas         *big.Int      // ceiling for the fee cap including bumps, nil for no ceiling
+	BumpPercent          uint64        // fee increase per replacement, nodes require at least 10
+	StuckAfter           time.Duration // pending time after which a transaction is replaced
+	MaxBumps             int
+}
+
+type QueuePolicy struct {
+	Path  string // submission queue file, empty keeps the queue in memory only
+	Depth int    // submissions in flight at once, 1 serializes them
+}
+
+type ConfirmationPolicy struct {
+	Depth        uint64        // blocks including the one the transaction was mined in
+	Timeout      time.Duration // overall time a submission may take, including replacements
+	PollInterval time.Duration
+}
+
+// SignerSource says where the submission key comes from. The first one set of ExternalSignerURL, KeystorePath and
+// the PrivateKeyEnv env var is used.
+type SignerSource struct {
+	// Clef compatible remote signer and the account to sign with, optional if the signer only manages one
+	ExternalSignerURL string
+	Account           common.Address
+	// Encrypted JSON keystore and the file holding its passphrase
+	KeystorePath   string
+	PassphraseFile string
+	// Env var holding the hex encoded submission key
+	PrivateKeyEnv string
+	// Fall back to the well known dev key when nothing else is set, refused on non-dev chains
+	AllowDevKey bool
+	// Vsock port of the host relayer. When set the enclave hands its submissions to the relayer, which signs with
+	// the key above, instead of holding a funded key itself. 0 makes the enclave sign.
+	RelayerPort uint32
+}
+
+// ChainProfile describes a deployment the client submits to.
+type ChainProfile struct {
+	Name         string
+	RPCURL       string
+	RPCVsockPort uint32 // host proxy the enclave reaches RPCURL through, one per chain
+	ChainID      uint64 // expected chain ID, 0 accepts whatever the node reports
+	Dev          bool   // local dev chain
+
+	RegistryAddress common.Address
+	OracleAddress   common.Address // optional, cross-checked against the registry when set
+	SignedUpdates   bool           // publish signed updates once the registry accepted the signing key
+
+	Gas          GasPolicy
+	Confirmation ConfirmationPolicy
+	Queue        QueuePolicy
+	Signer       SignerSource
+}
+
+// Named profiles. Testnet and mainnet have no deployment addresses baked in, they must come from the environment.
+var Profiles = map[string]ChainProfile{
+	"local": {
+		Name:            "local",
+		RPCURL:          "http://127.0.0.1:8545",
+		RPCVsockPort:    50003,
+		ChainID:         31337,
+		Dev:             true,
+		RegistryAddress: common.HexToAddress("0xDc64a140Aa3E981100a9becA4E685f962f0cF6C9"), // deployed right after the DKIMOracle
+		OracleAddress:   common.HexToAddress("0xCf7Ed3AccA5a467e9e704C703E8D87F634fB0Fc9"),
+		Gas: GasPolicy{
+			AttestationGasLimit:  60000000,
+			SignedUpdateGasLimit: 2000000,
+			BumpPercent:          15,
+			StuckAfter:           15 * time.Second,
+			MaxBumps:             5,
+		},
+		Confirmation: ConfirmationPolicy{
+			Depth:        1,
+			Timeout:      2 * time.Minute,
+			PollInterval: time.Second,
+		},
+		Signer: SignerSource{PrivateKeyEnv: "PRIVATE_KEY", AllowDevKey: true, RelayerPort: 50005},
+	},
+	"testnet": {
+		Name:         "testnet",
+		RPCURL:       "https://ethereum-sepolia-rpc.publicnode.com",
+		RPCVsockPort: 50013,
+		ChainID:      11155111,
+		Gas: GasPolicy{
+			AttestationGasLimit:  60000000,
+			SignedUpdateGasLimit: 2000000,
+			BumpPercent:          15,
+			StuckAfter:           time.Minute,
+			MaxBumps:             5,
+		},
+		Confirmation: ConfirmationPolicy{
+			Depth:        3,
+			Timeout:      10 * time.Minute,
+			PollInterval: 4 * time.Second,
+		},
+		Signer: SignerSource{PrivateKeyEnv: "PRIVATE_KEY", RelayerPort: 50015},
+	},
+	"mainnet": {
+		Name:         "mainnet",
+		RPCURL:       "https://ethereum-rpc.publicnode.com",
+		RPCVsockPort: 50023,
+		ChainID:      1,
+		Gas: GasPolicy{
+			AttestationGasLimit:  60000000,
+			SignedUpdateGasLimit: 2000000,
+			BumpPercent:          15,
+			StuckAfter:           2 * time.Minute,
+			MaxBumps:             5,
+		},
+		Confirmation: ConfirmationPolicy{
+			Depth:        12,
+			Timeout:      30 * time.Minute,
+			PollInterval: 6 * time.Second,
+		},
+		Signer: SignerSource{PrivateKeyEnv: "PRIVATE_KEY", RelayerPort: 50025},
+	},
+}
+
+// ProfileFromConfig returns the built-in profile the chain config names with its overrides applied.
+func ProfileFromConfig(chain config.ChainConfig) (*ChainProfile, error) {
+	base, ok := Profiles[chain.Profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain profile %q", chain.Profile)
+	}
+	profile := base
+
+	// One queue file per profile, nonces of different chains must not mix
+	profile.Queue = QueuePolicy{Path: fmt.Sprintf("submission-queue-%s.json", chain.Profile), Depth: 1}
+	if chain.SubmissionQueuePath != nil {
+		profile.Queue.Path = *chain.SubmissionQueuePath
+	}
+	if chain.PipelineDepth != 0 {
+		profile.Queue.Depth = chain.PipelineDepth
+	}
+	profile.SignedUpdates = chain.SignedUpdates
+
+	for _, override := range []struct {
+		value  string
+		target *string
+	}{
+		{chain.RPCURL, &profile.RPCURL},
+		{chain.ExternalSignerURL, &profile.Signer.ExternalSignerURL},
+		{chain.KeystorePath, &profile.Signer.KeystorePath},
+		{chain.KeystorePasswordFile, &profile.Signer.PassphraseFile},
+	} {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+
+	if chain.RPCVsockPort != 0 {
+		profile.RPCVsockPort = chain.RPCVsockPort
+	}
+	if chain.RelayerVsockPort != nil {
+		profile.Signer.RelayerPort = *chain.RelayerVsockPort
+	}
+
+	for _, override := range []struct {
+		value  uint64
+		target *uint64
+	}{
+		{chain.ChainID, &profile.ChainID},
+		{chain.AttestationGasLimit, &profile.Gas.AttestationGasLimit},
+		{chain.SignedUpdateGasLimit, &profile.Gas.SignedUpdateGasLimit},
+		{chain.ConfirmationDepth, &profile.Confirmation.Depth},
+	} {
+		if override.value != 0 {
+			*override.target = override.value
+		}
+	}
+
+	for name, override := range map[string]struct {
+		value  string
+		target *common.Address
+	}{
+		"registry address": {chain.RegistryAddress, &profile.RegistryAddress},
+		"oracle address":   {chain.OracleAddress, &profile.OracleAddress},
+		"signer address":   {chain.SignerAddress, &profile.Signer.Account},
+	} {
+		if override.value != "" {
+			if !common.IsHexAddress(override.value) {
+				return nil, fmt.Errorf("invalid %s %q for chain profile %s", name, override.value, chain.Profile)
+			}
+			*override.target = common.HexToAddress(override.value)
+		}
+	}
+
+	for name, override := range map[string]struct {
+		value  string
+		target **big.Int
+	}{
+		"max priority fee per gas": {chain.MaxPriorityFeePerGas, &profile.Gas.MaxPriorityFeePerGas},
+		"max fee per gas":          {chain.MaxFeePerGas, &profile.Gas.MaxFeePerGas},
+	} {
+		if override.value != "" {
+			wei, ok := new(big.Int).SetString(override.value, 10)
+			if !ok || wei.Sign() < 0 {
+				return nil, fmt.Errorf("invalid %s %q for chain profile %s", name, override.value, chain.Profile)
+			}
+			*override.target = wei
+		}
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ProfilesFromConfig returns the profiles of the configured chains. Profiles must not share a chain or a vsock port.
+func ProfilesFromConfig(chains []config.ChainConfig) ([]*ChainProfile, error) {
+	var profiles []*ChainProfile
+	chainIDs := map[uint64]string{}
+	ports := map[uint32]string{}
+	for _, chain := range chains {
+		profile, err := ProfileFromConfig(chain)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := chainIDs[profile.ChainID]; ok && profile.ChainID != 0 {
+			return nil, fmt.Errorf("chain profiles %s and %s both target chain %d", other, profile.Name, profile.ChainID)
+		}
+		chainIDs[profile.ChainID] = profile.Name
+
+		for _, port := range []uint32{profile.RPCVsockPort, profile.Signer.RelayerPort} {
+			if other, ok := ports[port]; ok && port != 0 {
+				return nil, fmt.Errorf("chain profiles %s and %s both use vsock port %d", other, profile.Name, port)
+			}
+			ports[port] = profile.Name
+		}
+
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// LoadProfiles returns the profiles of the chains configured through the environment alone, see config.Load.
+func LoadProfiles() ([]*ChainProfile, error) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ProfilesFromConfig(cfg.Chains)
+}
+
+func (p *ChainProfile) Validate() error {
+	if p.RPCURL == "" {
+		return fmt.Errorf("chain profile %s has no RPC URL", p.Name)
+	}
+	if p.RPCVsockPort == 0 {
+		return fmt.Errorf("chain profile %s has no RPC vsock port", p.Name)
+	}
+	if p.RegistryAddress == (common.Address{}) {
+		return fmt.Errorf("chain profile %s has no DKIMRegistry address, set DKIM_REGISTRY_ADDRESS or registry_address", p.Name)
+	}
+	if p.Gas.AttestationGasLimit == 0 || p.Gas.SignedUpdateGasLimit == 0 {
+		return fmt.Errorf("chain profile %s has no gas limits", p.Name)
+	}
+	if p.Gas.BumpPercent < 10 {
+		return fmt.Errorf("chain profile %s bumps fees by %d%%, nodes reject replacements below 10%%", p.Name, p.Gas.BumpPercent)
+	}
+	if p.Confirmation.Depth == 0 || p.Confirmation.Timeout <= 0 || p.Confirmation.PollInterval <= 0 {
+		return fmt.Errorf("chain profile %s has an incomplete confirmation policy", p.Name)
+	}
+	if p.Signer.KeystorePath != "" && p.Signer.PassphraseFile == "" {
+		return fmt.Errorf("chain profile %s has a keystore but no passphrase file, set KEYSTORE_PASSWORD_FILE or keystore_password_file", p.Name)
+	}
+	if p.Queue.Depth < 1 {
+		return fmt.Errorf("chain profile %s has a pipeline depth of %d", p.Name, p.Queue.Depth)
+	}
+	if p.Dev && p.ChainID != 0 && !devChainIDs[p.ChainID] {
+		return fmt.Errorf("chain profile %s is marked dev but expects chain ID %d", p.Name, p.ChainID)
+	}
+	return nil
+}
+
+// Verify detects the chain ID from the node and cross-checks it and the deployment against the profile.
+func (p *ChainProfile) Verify(ctx context.Context, client *ethclient.Client) (*big.Int, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	if p.ChainID != 0 && chainID.Uint64() != p.ChainID {
+		return nil, fmt.Errorf("chain profile %s expects chain ID %d but the node reports %s", p.Name, p.ChainID, chainID)
+	}
+	if p.Dev && !devChainIDs[chainID.Uint64()] {
+		return nil, fmt.Errorf("chain profile %s is marked dev but the node reports chain ID %s", p.Name, chainID)
+	}
+
+	code, err := client.CodeAt(ctx, p.RegistryAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code of DKIMRegistry %s: %v", p.RegistryAddress.Hex(), err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no contract deployed at DKIMRegistry address %s on chain %s", p.RegistryAddress.Hex(), chainID)
+	}
+
+	if p.OracleAddress != (common.Address{}) {
+		registry, err := NewRegistry(client, p.RegistryAddress)
+		if err != nil {
+			return nil, err
+		}
+		if registry.OracleAddress != p.OracleAddress {
+			return nil, fmt.Errorf("DKIMRegistry %s uses oracle %s, profile expects %s",
+				p.RegistryAddress.Hex(), registry.OracleAddress.Hex(), p.OracleAddress.Hex())
+		}
+	}
+
+	log.Infof("Verified chain profile %s: chain ID %s, DKIMRegistry %s", p.Name, chainID, p.RegistryAddress.Hex())
+	return chainID, nil
+}
+
+// signer opens the submission signer. The well known dev key is refused on any chain but a dev chain, whichever way
+// it was supplied.
+func (p *ChainProfile) signer(ctx context.Context, chainID *big.Int) (Signer, error) {
+	var signer Signer
+	switch {
+	case p.Signer.ExternalSignerURL != "":
+		clef, err := NewClefSigner(ctx, p.Signer.ExternalSignerURL, p.Signer.Account)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Signing with external signer at %s", p.Signer.ExternalSignerURL)
+		signer = clef
+
+	case p.Signer.KeystorePath != "":
+		local, err := LoadKeystoreSigner(p.Signer.KeystorePath, p.Signer.PassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Signing with keystore %s", p.Signer.KeystorePath)
+		signer = local
+
+	default:
+		privateKeyHex := strings.TrimPrefix(os.Getenv(p.Signer.PrivateKeyEnv), "0x")
+		if privateKeyHex == "" {
+			if !p.Signer.AllowDevKey {
+				return nil, fmt.Errorf("no submission key set, use %s, KEYSTORE_PATH or EXTERNAL_SIGNER_URL", p.Signer.PrivateKeyEnv)
+			}
+			privateKeyHex = defaultDevPrivateKey
+			log.Warnf("No %s env var set, using default Anvil key", p.Signer.PrivateKeyEnv)
+		}
+
+		privateKey, err := crypto.HexToECDSA(privateKeyHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		signer = NewLocalSigner(privateKey)
+	}
+
+	if signer.Address() == defaultDevAddress() && !devChainIDs[chainID.Uint64()] {
+		return nil, fmt.Errorf("refusing to use the default dev key on chain %s", chainID)
+	}
+	if p.Signer.Account != (common.Address{}) && signer.Address() != p.Signer.Account {
+		return nil, fmt.Errorf("submission key is for %s, profile expects %s", signer.Address().Hex(), p.Signer.Account.Hex())
+	}
+	return signer, nil
+}
+
+func defaultDevAddress() common.Address {
+	devKey, _ := crypto.HexToECDSA(defaultDevPrivateKey)
+	return crypto.PubkeyToAddress(devKey.PublicKey)
+}
+
+// transactor builds the transact options for the profile's submission signer on the given chain.
+func (p *ChainProfile) transactor(ctx context.Context, chainID *big.Int) (*bind.TransactOpts, error) {
+	signer, err := p.signer(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Using account address: %s", signer.Address().Hex())
+
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return signer.SignTx(ctx, tx, chainID)
+		},
+		Context: context.Background(),
+	}, nil
+}
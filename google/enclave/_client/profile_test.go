@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	t.Setenv("CHAIN_PROFILES", "local, testnet")
+	t.Setenv("TESTNET_DKIM_REGISTRY_ADDRESS", "0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	t.Setenv("TESTNET_RPC_URL", "https://rpc.example.org")
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		t.Fatalf("Failed to load profiles: %v", err)
+	}
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, "local", profiles[0].Name)
+	assert.Equal(t, Profiles["local"].RPCURL, profiles[0].RPCURL, "A prefixed override must only apply to its profile")
+	assert.Equal(t, "https://rpc.example.org", profiles[1].RPCURL)
+	assert.NotEqual(t, profiles[0].RPCVsockPort, profiles[1].RPCVsockPort)
+
+	t.Setenv("TESTNET_RPC_VSOCK_PORT", "50003")
+	_, err = LoadProfiles()
+	assert.Error(t, err, "Profiles sharing a vsock port must be rejected")
+}
+
+func TestSignerRefusesDevKey(t *testing.T) {
+	devKey := "0x" + defaultDevPrivateKey
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		profile string
+		key     string // PRIVATE_KEY, empty leaves it unset
+		err     string
+	}{
+		{"dev key on local", "local", devKey, ""},
+		{"dev key on testnet", "testnet", devKey, "refusing to use the default dev key on chain 11155111"},
+		{"dev key on mainnet", "mainnet", devKey, "refusing to use the default dev key on chain 1"},
+		{"fallback on local", "local", "", ""},
+		{"no fallback on testnet", "testnet", "", "no submission key set, use PRIVATE_KEY, KEYSTORE_PATH or EXTERNAL_SIGNER_URL"},
+		{"no fallback on mainnet", "mainnet", "", "no submission key set, use PRIVATE_KEY, KEYSTORE_PATH or EXTERNAL_SIGNER_URL"},
+		{"own key on mainnet", "mainnet", hexutil.Encode(crypto.FromECDSA(otherKey)), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("PRIVATE_KEY", test.key)
+			profile := Profiles[test.profile]
+
+			signer, err := profile.signer(context.Background(), new(big.Int).SetUint64(profile.ChainID))
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			if test.key == "" || test.key == devKey {
+				assert.Equal(t, defaultDevAddress(), signer.Address())
+			} else {
+				assert.Equal(t, crypto.PubkeyToAddress(otherKey.PublicKey), signer.Address())
+			}
+		})
+	}
+}
+
+// fakeChain answers the calls Verify makes.
+type fakeChain struct {
+	chainID uint64
+}
+
+func (f *fakeChain) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).SetUint64(f.chainID))
+}
+
+func (f *fakeChain) GetCode(address common.Address, block string) hexutil.Bytes {
+	return hexutil.Bytes{0x60, 0x80}
+}
+
+func TestVerifyChainID(t *testing.T) {
+	registry := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name    string
+		profile string
+		chainID uint64
+		err     string
+	}{
+		{"testnet", "testnet", 11155111, ""},
+		{"testnet profile on mainnet", "testnet", 1, "chain profile testnet expects chain ID 11155111 but the node reports 1"},
+		{"mainnet profile on testnet", "mainnet", 11155111, "chain profile mainnet expects chain ID 1 but the node reports 11155111"},
+		{"local profile on mainnet", "local", 1, "chain profile local expects chain ID 31337 but the node reports 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			defer server.Stop()
+			assert.NoError(t, server.RegisterName("eth", &fakeChain{chainID: test.chainID}))
+			client := ethclient.NewClient(rpc.DialInProc(server))
+			defer client.Close()
+
+			profile := Profiles[test.profile]
+			profile.RegistryAddress = registry
+			chainID, err := profile.Verify(context.Background(), client)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.chainID, chainID.Uint64())
+		})
+	}
+
+	// A dev profile accepting any chain ID still refuses a chain that is not a dev chain
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", &fakeChain{chainID: 1}))
+	client := ethclient.NewClient(rpc.DialInProc(server))
+	defer client.Close()
+
+	profile := Profiles["local"]
+	profile.ChainID, profile.RegistryAddress = 0, registry
+	_, err := profile.Verify(context.Background(), client)
+	assert.EqualError(t, err, "chain profile local is marked dev but the node reports chain ID 1")
+}
@@ -0,0 +1,339 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	KindAttestation  = "attestation"
+	KindSignedUpdate = "signed_update"
+
+	// Finished submissions kept in the queue file for inspection
+	maxQueueHistory = 100
+)
+
+var errSubmissionRunning = errors.New("already being processed")
+
+type QueueState string
+
+const (
+	QueueStateQueued  QueueState = "queued"  // waiting for a pipeline slot
+	QueueStatePending QueueState = "pending" // nonce assigned, possibly broadcast
+	QueueStateDone    QueueState = "done"
+)
+
+// QueuedSubmission is a submission as stored in the queue file.
+type QueuedSubmission struct {
+	ID         string             `json:"id"`
+	Kind       string             `json:"kind"`
+	Payload    []byte             `json:"payload"` // attestation or ABI encoded signed update
+	State      QueueState         `json:"state"`
+	Nonce      *uint64            `json:"nonce,omitempty"`
+	Attempts   []TxAttempt        `json:"attempts,omitempty"`
+	EnqueuedAt time.Time          `json:"enqueued_at"`
+	Outcome    *SubmissionOutcome `json:"outcome,omitempty"`
+
+	running bool
+}
+
+// SubmissionQueue persists submissions and their broadcast attempts, so a restart picks up transactions that were
+// in flight instead of sending them again at a fresh nonce. Up to the profile's pipeline depth submissions are in
+// flight at once with consecutive nonces, a depth of 1 serializes them.
+type SubmissionQueue struct {
+	mu      sync.Mutex
+	path    string
+	entries []*QueuedSubmission
+
+	client   TxBackend
+	profile  *ChainProfile
+	auth     *bind.TransactOpts
+	registry *Registry
+	nonces   *NonceManager
+	slots    chan struct{}
+}
+
+// OpenSubmissionQueue loads the queue file of the profile, if there is one, and reconciles the nonces of unfinished
+// submissions with the chain. Call Recover to resume them.
+func OpenSubmissionQueue(ctx context.Context, client TxBackend, profile *ChainProfile, registry *Registry,
+	auth *bind.TransactOpts) (*SubmissionQueue, error) {
+	q := &SubmissionQueue{
+		path:     profile.Queue.Path,
+		client:   client,
+		profile:  profile,
+		auth:     auth,
+		registry: registry,
+		nonces:   NewNonceManager(client, auth.From),
+		slots:    make(chan struct{}, profile.Queue.Depth),
+	}
+
+	if q.path != "" {
+		data, err := os.ReadFile(q.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read submission queue: %v", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &q.entries); err != nil {
+				return nil, fmt.Errorf("failed to parse submission queue %s: %v", q.path, err)
+			}
+		}
+	}
+
+	for _, entry := range q.entries {
+		if entry.State != QueueStateDone && entry.Nonce != nil && len(entry.Attempts) > 0 {
+			q.nonces.Track(*entry.Nonce, entry.Attempts[len(entry.Attempts)-1].Hash)
+		}
+	}
+	if err := q.nonces.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Opened submission queue %q with %d unfinished submissions, %d in flight",
+		q.path, len(q.Unfinished()), len(q.nonces.InFlight()))
+	return q, nil
+}
+
+// Submit queues the submission and blocks until it has an outcome. Earlier submissions that timed out are resumed
+// first, so a stuck transaction gets its fees bumped instead of holding up every later nonce. ctx only carries the
+// trace, the submission goes on if it is cancelled.
+func (q *SubmissionQueue) Submit(ctx context.Context, kind string, payload []byte) (*SubmissionOutcome, error) {
+	q.resume(context.WithoutCancel(ctx))
+
+	now := time.Now()
+	entry := &QueuedSubmission{
+		ID:         fmt.Sprintf("%s-%d-%s", kind, now.UnixNano(), crypto.Keccak256Hash(payload).Hex()[2:10]),
+		Kind:       kind,
+		Payload:    payload,
+		State:      QueueStateQueued,
+		EnqueuedAt: now,
+	}
+
+	q.mu.Lock()
+	q.entries = append(q.entries, entry)
+	err := q.persist()
+	q.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.process(context.WithoutCancel(ctx), entry)
+}
+
+// Recover resumes the submissions left unfinished by a previous run or a timeout, lowest nonce first.
+func (q *SubmissionQueue) Recover() {
+	unfinished := q.Unfinished()
+	if len(unfinished) == 0 {
+		return
+	}
+	log.Infof("Recovering %d unfinished submissions", len(unfinished))
+
+	var wg sync.WaitGroup
+	for _, entry := range unfinished {
+		wg.Add(1)
+		go func(entry *QueuedSubmission) {
+			defer wg.Done()
+			if _, err := q.process(context.Background(), entry); err != nil {
+				log.Errorf("Recovered submission %s failed: %v", entry.ID, err)
+			}
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// resume processes the unfinished submissions that hold a nonce and are not being processed, lowest nonce first.
+func (q *SubmissionQueue) resume(ctx context.Context) {
+	for _, entry := range q.Unfinished() {
+		q.mu.Lock()
+		nonce, running := entry.Nonce, entry.running
+		q.mu.Unlock()
+		if nonce == nil || running {
+			continue
+		}
+
+		log.Infof("Resuming submission %s at nonce %d", entry.ID, *nonce)
+		if _, err := q.process(ctx, entry); err != nil && !errors.Is(err, errSubmissionRunning) {
+			log.Errorf("Resumed submission %s failed: %v", entry.ID, err)
+		}
+	}
+}
+
+// Unfinished returns the submissions without a final outcome, ordered by nonce with unassigned ones last.
+func (q *SubmissionQueue) Unfinished() []*QueuedSubmission {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var unfinished []*QueuedSubmission
+	for _, entry := range q.entries {
+		if entry.State != QueueStateDone {
+			unfinished = append(unfinished, entry)
+		}
+	}
+	sort.SliceStable(unfinished, func(i, j int) bool {
+		a, b := unfinished[i].Nonce, unfinished[j].Nonce
+		return a != nil && (b == nil || *a < *b)
+	})
+	return unfinished
+}
+
+func (q *SubmissionQueue) process(ctx context.Context, entry *QueuedSubmission) (*SubmissionOutcome, error) {
+	gasLimit, build, err := q.builder(entry.Kind, entry.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	q.slots <- struct{}{}
+	defer func() { <-q.slots }()
+
+	q.mu.Lock()
+	if entry.running {
+		q.mu.Unlock()
+		return nil, fmt.Errorf("submission %s is %w", entry.ID, errSubmissionRunning)
+	}
+	entry.running = true
+	nonce := entry.Nonce
+	attempts := append([]TxAttempt(nil), entry.Attempts...)
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		entry.running = false
+		q.mu.Unlock()
+	}()
+
+	if nonce == nil {
+		reserveCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		reserved, err := q.nonces.Reserve(reserveCtx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		nonce = &reserved
+
+		if err := q.update(entry, func() { entry.Nonce = nonce; entry.State = QueueStatePending }); err != nil {
+			q.nonces.Release(reserved)
+			return nil, err
+		}
+	}
+
+	outcome, err := sendTransaction(ctx, q.client, q.profile, q.auth, &txRequest{
+		kind:     entry.Kind,
+		gasLimit: gasLimit,
+		nonce:    *nonce,
+		build:    build,
+		attempts: attempts,
+		record: func(attempts []TxAttempt) error {
+			if len(attempts) > 0 {
+				q.nonces.Track(*nonce, attempts[len(attempts)-1].Hash)
+			}
+			return q.update(entry, func() { entry.Attempts = attempts })
+		},
+	})
+
+	persistErr := q.update(entry, func() {
+		entry.Outcome = outcome
+		switch {
+		case len(outcome.Attempts) == 0:
+			// Never broadcast, the nonce is free again and the submission is given up
+			q.nonces.Release(*nonce)
+			entry.Nonce = nil
+			entry.State = QueueStateDone
+		case outcome.Status == StatusTimedOut:
+			// Still in the mempool, left pending for the next Submit or Recover
+		default:
+			q.nonces.Done(*nonce)
+			entry.State = QueueStateDone
+		}
+	})
+	if persistErr != nil {
+		log.Errorf("Failed to persist outcome of submission %s: %v", entry.ID, persistErr)
+	}
+	return outcome, err
+}
+
+func (q *SubmissionQueue) builder(kind string, payload []byte) (uint64, func(auth *bind.TransactOpts) (*types.Transaction, error), error) {
+	switch kind {
+	case KindAttestation:
+		return q.profile.Gas.AttestationGasLimit, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return q.registry.StoreAttestation(auth, payload)
+		}, nil
+	case KindSignedUpdate:
+		return q.profile.Gas.SignedUpdateGasLimit, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return q.registry.StoreSignedUpdate(auth, payload)
+		}, nil
+	default:
+		return 0, nil, fmt.Errorf("unknown submission kind %q", kind)
+	}
+}
+
+func (q *SubmissionQueue) update(entry *QueuedSubmission, change func()) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	change()
+	return q.persist()
+}
+
+// persist writes the queue file atomically, dropping the oldest finished submissions beyond maxQueueHistory. Must be
+// called with q.mu held.
+func (q *SubmissionQueue) persist() error {
+	done := 0
+	for _, entry := range q.entries {
+		if entry.State == QueueStateDone {
+			done++
+		}
+	}
+	if done > maxQueueHistory {
+		entries := q.entries[:0]
+		for _, entry := range q.entries {
+			if entry.State == QueueStateDone && done > maxQueueHistory {
+				done--
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		q.entries = entries
+	}
+
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission queue: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write submission queue: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write submission queue: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write submission queue: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write submission queue: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("failed to write submission queue: %v", err)
+	}
+	return nil
+}
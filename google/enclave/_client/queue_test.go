@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmissionQueueRecoversUnconfirmedEntry(t *testing.T) {
+	// A submission broadcast at nonce 3 before a restart, mined while the enclave was down
+	hash := common.HexToHash("0xabcdef")
+	nonce := uint64(3)
+	path := filepath.Join(t.TempDir(), "queue.json")
+	data, err := json.Marshal([]*QueuedSubmission{{
+		ID:       "attestation-1",
+		Kind:     KindAttestation,
+		Payload:  []byte{0x01},
+		State:    QueueStatePending,
+		Nonce:    &nonce,
+		Attempts: []TxAttempt{{Hash: hash, GasTipCap: common.Big1, GasFeeCap: common.Big2, SentAt: time.Now()}},
+	}})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	backend := newFakeBackend(100, 3)
+	backend.mine(hash, 100)
+	backend.mined = 4
+
+	profile := testProfile(2)
+	profile.Queue.Path = path
+	auth := &bind.TransactOpts{From: common.HexToAddress("0x01")}
+	queue, err := OpenSubmissionQueue(context.Background(), backend, profile, nil, auth)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Unfinished(), 1)
+	assert.Equal(t, map[uint64]common.Hash{3: hash}, queue.nonces.InFlight())
+
+	queue.Recover()
+
+	assert.Empty(t, queue.Unfinished())
+	assert.Empty(t, queue.nonces.InFlight())
+	assert.Empty(t, backend.sent, "a mined submission must not be sent again")
+
+	entry := queue.entries[0]
+	assert.Equal(t, QueueStateDone, entry.State)
+	assert.Equal(t, StatusConfirmed, entry.Outcome.Status)
+	assert.Equal(t, hash, entry.Outcome.TxHash)
+
+	// The outcome is on disk for the next restart
+	reopened, err := OpenSubmissionQueue(context.Background(), backend, profile, nil, auth)
+	assert.NoError(t, err)
+	assert.Empty(t, reopened.Unfinished())
+
+	// The recovered nonce is not handed out again
+	next, err := queue.nonces.Reserve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), next)
+}
+
+// contractBackend completes fakeBackend into what the generated contract bindings need to build transactions.
+type contractBackend struct {
+	*fakeBackend
+}
+
+func (contractBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (contractBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	return nil, nil
+}
+
+func (contractBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (contractBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1_000_000_000), nil
+}
+
+func (contractBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (contractBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestSubmitResumesTimedOutEntry(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	backend := newFakeBackend(100, 0)
+	bound, err := contracts.NewDKIMRegistry(common.HexToAddress("0xDc64a140Aa3E981100a9becA4E685f962f0cF6C9"), contractBackend{backend})
+	assert.NoError(t, err)
+	auth, err := bind.NewKeyedTransactorWithChainID(key, backend.chainID)
+	assert.NoError(t, err)
+
+	// The first submission is never mined and times out before it is considered stuck
+	profile := testProfile(1)
+	profile.Gas.StuckAfter = 200 * time.Millisecond
+	profile.Confirmation.Timeout = 50 * time.Millisecond
+	queue, err := OpenSubmissionQueue(context.Background(), backend, profile, &Registry{registry: bound}, auth)
+	assert.NoError(t, err)
+
+	outcome, err := queue.Submit(context.Background(), KindAttestation, []byte{0x01})
+	assert.Error(t, err)
+	assert.Equal(t, StatusTimedOut, outcome.Status)
+	assert.Len(t, queue.Unfinished(), 1)
+	assert.Equal(t, map[uint64]common.Hash{0: outcome.Attempts[0].Hash}, queue.nonces.InFlight())
+
+	// From now on everything sent is mined
+	profile.Confirmation.Timeout = 5 * time.Second
+	backend.onSend = func(f *fakeBackend, tx *types.Transaction) {
+		f.mine(tx.Hash(), f.head)
+		f.mined = tx.Nonce() + 1
+	}
+
+	outcome, err = queue.Submit(context.Background(), KindAttestation, []byte{0x02})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusConfirmed, outcome.Status)
+	assert.Equal(t, uint64(1), outcome.Nonce, "the new submission goes behind the resumed one")
+	assert.Empty(t, queue.Unfinished())
+	assert.Empty(t, queue.nonces.InFlight())
+
+	resumed := queue.entries[0]
+	assert.Equal(t, QueueStateDone, resumed.State)
+	assert.Equal(t, StatusConfirmed, resumed.Outcome.Status)
+	assert.Len(t, resumed.Outcome.Attempts, 2, "the stuck transaction must be replaced with bumped fees")
+	assert.Equal(t, resumed.Outcome.Attempts[1].Hash, resumed.Outcome.TxHash)
+
+	var nonces []uint64
+	for _, tx := range backend.sent {
+		nonces = append(nonces, tx.Nonce())
+	}
+	assert.Equal(t, []uint64{0, 0, 1}, nonces)
+}
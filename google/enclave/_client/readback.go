@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/contracts"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyMismatch is a key whose state on chain differs from what the enclave attested.
+type KeyMismatch struct {
+	Key      string `json:"key"`     // domain;selector
+	Problem  string `json:"problem"` // missing, different, event_different or stale
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// ReadBackError reports that the registry does not hold what was attested after a confirmed submission.
+type ReadBackError struct {
+	TxHash     common.Hash
+	Mismatches []KeyMismatch
+}
+
+func (e *ReadBackError) Error() string {
+	keys := make([]string, len(e.Mismatches))
+	for i, mismatch := range e.Mismatches {
+		keys[i] = fmt.Sprintf("%s (%s)", mismatch.Key, mismatch.Problem)
+	}
+	return fmt.Sprintf("registry state does not match attested keys after %s: %s", e.TxHash.Hex(), strings.Join(keys, ", "))
+}
+
+// RegisteredKeys decodes the KeyRegistered events the registry emitted in the receipt.
+func (r *Registry) RegisteredKeys(receipt *types.Receipt) []*contracts.DKIMRegistryKeyRegistered {
+	var events []*contracts.DKIMRegistryKeyRegistered
+	for _, entry := range receipt.Logs {
+		if entry.Address != r.Address || len(entry.Topics) == 0 {
+			continue
+		}
+		event, err := r.registry.ParseKeyRegistered(*entry)
+		if err != nil {
+			// Some other event of the registry
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// VerifyReadBack checks the registry after the submission in txHash was confirmed. Every key in userData, the
+// flattened payload that was attested or signed, must be stored byte for byte, and keys registered by the
+// transaction must carry the attested value. Mismatches are logged as an alert and returned as a ReadBackError.
+// A key the registry already held with an older value before the transaction is stale rather than a mismatch: the
+// registry never overwrites it, so it is only logged as a warning, DecideSubmission alerts on it every cycle.
+func (r *Registry) VerifyReadBack(ctx context.Context, client *ethclient.Client, txHash common.Hash, userData []byte) error {
+	expected, err := schema.DecodeV0(userData)
+	if err != nil {
+		return fmt.Errorf("failed to decode submitted keys: %v", err)
+	}
+
+	receipt, err := client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("failed to get receipt of %s: %v", txHash.Hex(), err)
+	}
+
+	events := r.RegisteredKeys(receipt)
+	// Domain and selector are indexed, so the events only carry their hashes
+	registered := make(map[[2]common.Hash][]byte, len(events))
+	for _, event := range events {
+		registered[[2]common.Hash{event.Domain, event.Selector}] = event.PublicKey
+	}
+
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mismatches, stale []KeyMismatch
+	for _, name := range names {
+		domain, selector, _ := strings.Cut(name, ";")
+		want := []byte(expected[name])
+
+		stored, found, err := r.GetDKIMKey(ctx, domain, selector)
+		if err != nil {
+			return err
+		}
+		topics := [2]common.Hash{crypto.Keccak256Hash([]byte(domain)), crypto.Keccak256Hash([]byte(selector))}
+		event, registeredNow := registered[topics]
+		switch {
+		case !found:
+			mismatches = append(mismatches, KeyMismatch{Key: name, Problem: "missing", Expected: keyDigest(want)})
+		case !bytes.Equal(stored, want) && !registeredNow:
+			// Registered with an older key before this transaction
+			stale = append(stale, KeyMismatch{
+				Key: name, Problem: "stale", Expected: keyDigest(want), Actual: keyDigest(stored),
+			})
+		case !bytes.Equal(stored, want):
+			mismatches = append(mismatches, KeyMismatch{
+				Key: name, Problem: "different", Expected: keyDigest(want), Actual: keyDigest(stored),
+			})
+		}
+
+		if registeredNow && !bytes.Equal(event, want) {
+			mismatches = append(mismatches, KeyMismatch{
+				Key: name, Problem: "event_different", Expected: keyDigest(want), Actual: keyDigest(event),
+			})
+		}
+	}
+
+	if len(stale) > 0 {
+		log.WithFields(log.Fields{
+			"tx_hash":  txHash.Hex(),
+			"registry": r.Address.Hex(),
+			"stale":    stale,
+		}).Warnf("Registry already holds an older key for %d submitted keys and kept it", len(stale))
+	}
+
+	if len(mismatches) > 0 {
+		log.WithFields(log.Fields{
+			"severity":   "alert",
+			"tx_hash":    txHash.Hex(),
+			"registry":   r.Address.Hex(),
+			"mismatches": mismatches,
+		}).Errorf("🚨 Registry does not hold the attested keys after submission")
+		return &ReadBackError{TxHash: txHash, Mismatches: mismatches}
+	}
+
+	log.WithFields(log.Fields{
+		"tx_hash":    txHash.Hex(),
+		"verified":   len(names) - len(stale),
+		"stale":      len(stale),
+		"registered": len(events),
+	}).Infof("✅ Read back every submitted key from the registry")
+	return nil
+}
+
+// keyDigest identifies a stored key in logs without dumping it.
+func keyDigest(key []byte) string {
+	return fmt.Sprintf("keccak:%s len:%d", crypto.Keccak256Hash(key).Hex(), len(key))
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/contracts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// signedUpdateABI is the registry entry point for updates signed by the attested enclave key. It is not part of the
+// generated bindings because the deployed DKIMRegistry does not implement it yet, SupportsSignedUpdates tells whether
+// a registry does.
+const signedUpdateABI = `[{"type":"function","name":"storeDKIMKeysFromSignedUpdate","inputs":[{"name":"update","type":"bytes"}],"outputs":[],"stateMutability":"nonpayable"}]`
+
+var signedUpdateSelector = crypto.Keccak256([]byte("storeDKIMKeysFromSignedUpdate(bytes)"))[:4]
+
+// Registry wraps the DKIMRegistry contract and the DKIMOracle it validates attestations with.
+type Registry struct {
+	Address       common.Address
+	OracleAddress common.Address
+
+	registry      *contracts.DKIMRegistry
+	oracle        *contracts.DKIMOracle
+	signedUpdates *bind.BoundContract
+}
+
+// DKIMKey is a key stored in the registry.
+type DKIMKey struct {
+	Domain    string
+	Selector  string
+	PublicKey []byte
+}
+
+// AttestationFields are the attestation document fields located by DKIMOracle, sliced out of the to-be-signed
+// structure the pointers index into.
+type AttestationFields struct {
+	ModuleID    string
+	Timestamp   uint64
+	Digest      string
+	PCRs        [][]byte
+	Certificate []byte
+	CABundle    [][]byte
+	PublicKey   []byte // nil if the field is null
+	UserData    []byte // nil if the field is null
+	Nonce       []byte // nil if the field is null
+}
+
+// NewRegistry binds the registry at the given address and looks up the oracle it was deployed with.
+func NewRegistry(client *ethclient.Client, address common.Address) (*Registry, error) {
+	registry, err := contracts.NewDKIMRegistry(address, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind DKIMRegistry: %v", err)
+	}
+
+	oracleAddress, err := registry.DkimOracle(&bind.CallOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DKIMOracle address from registry %s: %v", address.Hex(), err)
+	}
+
+	oracle, err := contracts.NewDKIMOracle(oracleAddress, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind DKIMOracle: %v", err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(signedUpdateABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed update ABI: %v", err)
+	}
+
+	return &Registry{
+		Address:       address,
+		OracleAddress: oracleAddress,
+		registry:      registry,
+		oracle:        oracle,
+		signedUpdates: bind.NewBoundContract(address, parsed, client, client, client),
+	}, nil
+}
+
+// ValidateAttestation runs DKIMOracle.decodeAndValidateAttestation as a call and decodes the returned Ptrs. Nothing is
+// stored, but the oracle performs the full validation, so this fails if the transaction would.
+func (r *Registry) ValidateAttestation(ctx context.Context, attestation []byte) (*AttestationFields, error) {
+	raw := &contracts.DKIMOracleRaw{Contract: r.oracle}
+
+	var out []interface{}
+	err := raw.Call(&bind.CallOpts{Context: ctx}, &out, "decodeAndValidateAttestation", attestation)
+	if err != nil {
+		return nil, fmt.Errorf("attestation validation failed: %v", decodeRevert(err))
+	}
+
+	ptrs := *abi.ConvertType(out[0], new(contracts.DKIMOraclePtrs)).(*contracts.DKIMOraclePtrs)
+
+	// The pointers index into the to-be-signed structure, not into the attestation itself
+	tbs, err := r.oracle.DecodeAttestationTbs(&bind.CallOpts{Context: ctx}, attestation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attestation tbs: %v", err)
+	}
+
+	return decodePtrs(tbs.AttestationTbs, &ptrs)
+}
+
+// StoreAttestation sends DKIMRegistry.storeDKIMKeysFromAttestation.
+func (r *Registry) StoreAttestation(opts *bind.TransactOpts, attestation []byte) (*types.Transaction, error) {
+	return r.registry.StoreDKIMKeysFromAttestation(opts, attestation)
+}
+
+// SupportsSignedUpdates looks for the storeDKIMKeysFromSignedUpdate selector in the registry code. The Solidity
+// dispatcher compares the call's selector with a PUSH4 (0x63) of every function selector, so a registry that does not
+// implement the function does not contain one for it.
+func (r *Registry) SupportsSignedUpdates(ctx context.Context, client bind.ContractCaller) (bool, error) {
+	code, err := client.CodeAt(ctx, r.Address, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get registry code: %v", err)
+	}
+	return bytes.Contains(code, append([]byte{0x63}, signedUpdateSelector...)), nil
+}
+
+// StoreSignedUpdate sends an ABI encoded identity.SignedUpdate to the registry.
+func (r *Registry) StoreSignedUpdate(opts *bind.TransactOpts, encodedUpdate []byte) (*types.Transaction, error) {
+	return r.signedUpdates.Transact(opts, "storeDKIMKeysFromSignedUpdate", encodedUpdate)
+}
+
+// GetDKIMKey returns the stored key and whether there is one.
+func (r *Registry) GetDKIMKey(ctx context.Context, domain string, selector string) ([]byte, bool, error) {
+	key, err := r.registry.GetDKIMKey(&bind.CallOpts{Context: ctx}, domain, selector)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get DKIM key %s;%s: %v", domain, selector, err)
+	}
+	return key.PublicKey, key.IsValid, nil
+}
+
+// GetAllDKIMKeys returns every key in the registry. The contract marks this as a dev debugging function.
+func (r *Registry) GetAllDKIMKeys(ctx context.Context) ([]DKIMKey, error) {
+	all, err := r.registry.GetAllDKIMKeys(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all DKIM keys: %v", err)
+	}
+
+	if len(all.Domains) != len(all.Selectors) || len(all.Domains) != len(all.PublicKeys) {
+		return nil, fmt.Errorf("registry returned mismatched key lists")
+	}
+
+	keys := make([]DKIMKey, len(all.Domains))
+	for i := range all.Domains {
+		keys[i] = DKIMKey{
+			Domain:    all.Domains[i],
+			Selector:  all.Selectors[i],
+			PublicKey: all.PublicKeys[i],
+		}
+	}
+	return keys, nil
+}
+
+// cborElement mirrors the CborElement packing in CborDecode.sol: type in bits 0-7, content start in bits 80-159 and
+// value (length for strings) in bits 160-223.
+type cborElement struct {
+	typ   uint8
+	start uint64
+	value uint64
+}
+
+func unpackCborElement(packed *big.Int) cborElement {
+	mask64 := new(big.Int).SetUint64(^uint64(0))
+	return cborElement{
+		typ:   uint8(packed.Uint64()),
+		start: new(big.Int).And(new(big.Int).Rsh(packed, 80), mask64).Uint64(),
+		value: new(big.Int).And(new(big.Int).Rsh(packed, 160), mask64).Uint64(),
+	}
+}
+
+func (e cborElement) isNull() bool {
+	return e.typ == 0xf6 || e.typ == 0xf7
+}
+
+func (e cborElement) slice(data []byte) ([]byte, error) {
+	// An element that was never set is zero, e.g. an optional field the attestation does not have
+	if e.isNull() || e == (cborElement{}) {
+		return nil, nil
+	}
+	if e.typ != 0x40 && e.typ != 0x60 {
+		return nil, fmt.Errorf("cbor element of type 0x%x is not a string", e.typ)
+	}
+
+	end := e.start + e.value
+	if end < e.start || end > uint64(len(data)) {
+		return nil, fmt.Errorf("cbor element [%d, %d) out of range of %d bytes", e.start, end, len(data))
+	}
+	return data[e.start:end], nil
+}
+
+func decodePtrs(tbs []byte, ptrs *contracts.DKIMOraclePtrs) (*AttestationFields, error) {
+	fields := &AttestationFields{Timestamp: ptrs.Timestamp}
+
+	sliceAt := func(name string, packed *big.Int) ([]byte, error) {
+		data, err := unpackCborElement(packed).slice(tbs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pointer: %v", name, err)
+		}
+		return data, nil
+	}
+
+	moduleID, err := sliceAt("module_id", ptrs.ModuleID)
+	if err != nil {
+		return nil, err
+	}
+	fields.ModuleID = string(moduleID)
+
+	digest, err := sliceAt("digest", ptrs.Digest)
+	if err != nil {
+		return nil, err
+	}
+	fields.Digest = string(digest)
+
+	for i, pcr := range ptrs.Pcrs {
+		data, err := sliceAt(fmt.Sprintf("pcr %d", i), pcr)
+		if err != nil {
+			return nil, err
+		}
+		fields.PCRs = append(fields.PCRs, data)
+	}
+
+	if fields.Certificate, err = sliceAt("certificate", ptrs.Cert); err != nil {
+		return nil, err
+	}
+
+	for i, cert := range ptrs.Cabundle {
+		data, err := sliceAt(fmt.Sprintf("cabundle %d", i), cert)
+		if err != nil {
+			return nil, err
+		}
+		fields.CABundle = append(fields.CABundle, data)
+	}
+
+	if fields.PublicKey, err = sliceAt("public_key", ptrs.PublicKey); err != nil {
+		return nil, err
+	}
+	if fields.UserData, err = sliceAt("user_data", ptrs.UserData); err != nil {
+		return nil, err
+	}
+	if fields.Nonce, err = sliceAt("nonce", ptrs.Nonce); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
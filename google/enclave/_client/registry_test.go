@@ -0,0 +1,165 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// tbsBuilder writes CBOR strings and returns pointers to them packed like LibCborElement.toCborElement:
+// type | start << 80 | length << 160.
+type tbsBuilder struct {
+	bytes.Buffer
+}
+
+func (b *tbsBuilder) text(s string) *big.Int {
+	return b.write(0x60, []byte(s))
+}
+
+func (b *tbsBuilder) bytes(data []byte) *big.Int {
+	return b.write(0x40, data)
+}
+
+func (b *tbsBuilder) null() *big.Int {
+	b.WriteByte(0xf6)
+	return element(0xf6, uint64(b.Len()), 0)
+}
+
+func (b *tbsBuilder) write(typ byte, content []byte) *big.Int {
+	switch n := len(content); {
+	case n < 24:
+		b.WriteByte(typ | byte(n))
+	case n < 256:
+		b.Write([]byte{typ | 24, byte(n)})
+	default:
+		b.WriteByte(typ | 25)
+		binary.Write(&b.Buffer, binary.BigEndian, uint16(n))
+	}
+	start := uint64(b.Len())
+	b.Write(content)
+	return element(uint64(typ), start, uint64(len(content)))
+}
+
+func element(typ uint64, start uint64, length uint64) *big.Int {
+	packed := new(big.Int).SetUint64(typ)
+	packed.Or(packed, new(big.Int).Lsh(new(big.Int).SetUint64(start), 80))
+	return packed.Or(packed, new(big.Int).Lsh(new(big.Int).SetUint64(length), 160))
+}
+
+func TestDecodePtrs(t *testing.T) {
+	cert := bytes.Repeat([]byte{0x30}, 600) // two byte length header
+	pcr0 := bytes.Repeat([]byte{0xaa}, 48)
+	userData := []byte{0xa1, 0x61, 0x6b, 0x61, 0x76}
+
+	var tbs tbsBuilder
+	tbs.Write([]byte{0x84, 0x6a}) // Sig_structure and its context, skipped by the pointers
+	tbs.WriteString("Signature1")
+	tbs.text("module_id")
+	moduleID := tbs.text("i-0123456789abcdef0-enc0123456789abcd")
+	tbs.text("digest")
+	digest := tbs.text("SHA384")
+	tbs.text("pcrs")
+	pcrs := []*big.Int{tbs.bytes(pcr0), tbs.bytes(make([]byte, 48))}
+	tbs.text("certificate")
+	certificate := tbs.bytes(cert)
+	tbs.text("cabundle")
+	cabundle := []*big.Int{tbs.bytes([]byte{0x01, 0x02}), tbs.bytes([]byte{0x03})}
+	tbs.text("public_key")
+	publicKey := tbs.null()
+	tbs.text("user_data")
+	userDataPtr := tbs.bytes(userData)
+
+	ptrs := &contracts.DKIMOraclePtrs{
+		ModuleID:  moduleID,
+		Timestamp: 1_700_000_000_000,
+		Digest:    digest,
+		Pcrs:      pcrs,
+		Cert:      certificate,
+		Cabundle:  cabundle,
+		PublicKey: publicKey,
+		UserData:  userDataPtr,
+		Nonce:     new(big.Int), // not in the document
+	}
+
+	fields, err := decodePtrs(tbs.Bytes(), ptrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "i-0123456789abcdef0-enc0123456789abcd", fields.ModuleID)
+	assert.Equal(t, uint64(1_700_000_000_000), fields.Timestamp)
+	assert.Equal(t, "SHA384", fields.Digest)
+	assert.Equal(t, [][]byte{pcr0, make([]byte, 48)}, fields.PCRs)
+	assert.Equal(t, cert, fields.Certificate)
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03}}, fields.CABundle)
+	assert.Nil(t, fields.PublicKey)
+	assert.Equal(t, userData, fields.UserData)
+	assert.Nil(t, fields.Nonce)
+
+	invalid := []struct {
+		name  string
+		patch func(p *contracts.DKIMOraclePtrs)
+		err   string
+	}{
+		{"past the end", func(p *contracts.DKIMOraclePtrs) { p.UserData = element(0x40, uint64(tbs.Len()-2), 5) },
+			"invalid user_data pointer: cbor element"},
+		{"not a string", func(p *contracts.DKIMOraclePtrs) { p.Digest = element(0xa0, 10, 1) },
+			"invalid digest pointer: cbor element of type 0xa0 is not a string"},
+		{"bad pcr", func(p *contracts.DKIMOraclePtrs) { p.Pcrs = []*big.Int{element(0x40, 1<<40, 48)} },
+			"invalid pcr 0 pointer: cbor element"},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			patched := *ptrs
+			test.patch(&patched)
+			_, err := decodePtrs(tbs.Bytes(), &patched)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+// fakeCode answers eth_getCode with code.
+type fakeCode struct {
+	code []byte
+}
+
+func (f *fakeCode) GetCode(address common.Address, block string) hexutil.Bytes {
+	return f.code
+}
+
+func TestSupportsSignedUpdates(t *testing.T) {
+	dispatch := func(selector string) []byte {
+		return append(append([]byte{0x80, 0x63}, common.FromHex(selector)...), 0x14) // DUP1 PUSH4 selector EQ
+	}
+
+	tests := []struct {
+		name string
+		code []byte
+		want bool
+	}{
+		{"implemented", append(dispatch("0x12345678"), dispatch(hexutil.Encode(signedUpdateSelector))...), true},
+		{"other functions only", dispatch("0x12345678"), false},
+		{"selector not pushed", append([]byte{0x60}, signedUpdateSelector...), false},
+		{"no code", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			defer server.Stop()
+			assert.NoError(t, server.RegisterName("eth", &fakeCode{code: test.code}))
+			client := ethclient.NewClient(rpc.DialInProc(server))
+			defer client.Close()
+
+			registry := &Registry{Address: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")}
+			supported, err := registry.SupportsSignedUpdates(context.Background(), client)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, supported)
+		})
+	}
+}
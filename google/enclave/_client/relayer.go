@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+)
+
+// RelayerPath is where the host relayer accepts submissions.
+const RelayerPath = "/submit"
+
+// MaxRelayRequestSize bounds a submission sent to the relayer, attestations are a few kB.
+const MaxRelayRequestSize = 1 << 20
+
+// Submitter gets a submission on chain and reports its outcome. The outcome is returned whenever a transaction was
+// attempted, also on error. ctx only carries the trace, a submission is not cancelled once it was handed over.
+type Submitter interface {
+	Submit(ctx context.Context, kind string, payload []byte) (*SubmissionOutcome, error)
+}
+
+// RelayRequest is a submission the enclave hands to the host relayer.
+type RelayRequest struct {
+	Kind    string `json:"kind"`
+	Payload []byte `json:"payload"`
+}
+
+// RelayResponse reports the outcome of a relayed submission back to the enclave.
+type RelayResponse struct {
+	Outcome *SubmissionOutcome `json:"outcome,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// RelayerClient submits through the relayer on the host, which holds the funded key and handles gas, nonces and
+// confirmations. The enclave only produces attestations and signed updates.
+type RelayerClient struct {
+	httpClient *http.Client
+}
+
+// NewRelayerClient talks to the relayer listening on vsockPort of the host hostCID. timeout covers the whole
+// submission, including waiting for confirmations.
+func NewRelayerClient(hostCID uint32, vsockPort uint32, timeout time.Duration) *RelayerClient {
+	return &RelayerClient{
+		httpClient: &http.Client{
+			Transport: &network.VsockHTTPRoundTripper{
+				CID:  hostCID,
+				Port: vsockPort,
+			},
+			Timeout: timeout,
+		},
+	}
+}
+
+func (r *RelayerClient) Submit(ctx context.Context, kind string, payload []byte) (*SubmissionOutcome, error) {
+	body, err := json.Marshal(&RelayRequest{Kind: kind, Payload: payload})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal relay request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, "http://relayer"+RelayerPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relay request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach relayer: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("relayer returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+
+	var relayResp RelayResponse
+	if err := json.NewDecoder(resp.Body).Decode(&relayResp); err != nil {
+		return nil, fmt.Errorf("failed to decode relayer response: %v", err)
+	}
+	if relayResp.Error != "" {
+		return relayResp.Outcome, fmt.Errorf("relayer: %s", relayResp.Error)
+	}
+	if relayResp.Outcome == nil {
+		return nil, fmt.Errorf("relayer returned no outcome")
+	}
+	return relayResp.Outcome, nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signer signs submission transactions for one account.
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner signs with a key in memory: a raw PRIVATE_KEY, a decrypted keystore or a throwaway key in tests.
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// LoadKeystoreSigner decrypts a go-ethereum JSON keystore file with the passphrase in passphraseFile. A trailing
+// newline in the passphrase file is ignored.
+func LoadKeystoreSigner(keystorePath string, passphraseFile string) (*LocalSigner, error) {
+	keyJSON, err := os.ReadFile(keystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	passphrase, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore passphrase: %v", err)
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(string(passphrase), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %s: %v", keystorePath, err)
+	}
+	return NewLocalSigner(key.PrivateKey), nil
+}
+
+// ClefSigner signs through a remote signer speaking Clef's external API (account_list, account_signTransaction), so
+// the key never leaves the signer.
+type ClefSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewClefSigner connects to the signer at endpoint. The account must be one the signer manages, the zero address
+// picks the only one if there is exactly one.
+func NewClefSigner(ctx context.Context, endpoint string, account common.Address) (*ClefSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial external signer: %v", err)
+	}
+
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list external signer accounts: %v", err)
+	}
+
+	if account == (common.Address{}) {
+		if len(accounts) != 1 {
+			client.Close()
+			return nil, fmt.Errorf("external signer manages %d accounts, set SIGNER_ADDRESS to pick one", len(accounts))
+		}
+		account = accounts[0]
+	} else {
+		found := false
+		for _, managed := range accounts {
+			found = found || managed == account
+		}
+		if !found {
+			client.Close()
+			return nil, fmt.Errorf("external signer does not manage account %s", account.Hex())
+		}
+	}
+
+	return &ClefSigner{client: client, address: account}, nil
+}
+
+func (s *ClefSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *ClefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	data := hexutil.Bytes(tx.Data())
+	accessList := tx.AccessList()
+	args := &apitypes.SendTxArgs{
+		From:                 common.NewMixedcaseAddress(s.address),
+		Gas:                  hexutil.Uint64(tx.Gas()),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+		Value:                hexutil.Big(*tx.Value()),
+		Nonce:                hexutil.Uint64(tx.Nonce()),
+		Input:                &data,
+		AccessList:           &accessList,
+		ChainID:              (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("external signer refused to sign: %v", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid transaction: %v", err)
+	}
+
+	// Only broadcast what was asked for, signed by the expected account
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, fmt.Errorf("external signer returned a different transaction")
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid signature: %v", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("external signer signed with %s instead of %s", sender.Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClef implements the account namespace of Clef's external API with a LocalSigner.
+type fakeClef struct {
+	signer *LocalSigner
+	tamper bool
+}
+
+func (c *fakeClef) List() []common.Address {
+	return []common.Address{c.signer.Address()}
+}
+
+func (c *fakeClef) SignTransaction(args apitypes.SendTxArgs) (map[string]interface{}, error) {
+	if c.tamper {
+		args.Nonce++
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := c.signer.SignTx(context.Background(), tx, (*big.Int)(args.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0xDc64a140Aa3E981100a9becA4E685f962f0cF6C9")
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(3_000_000_000),
+		Gas:       2_000_000,
+		To:        &to,
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+func newTestSigner(t *testing.T) *LocalSigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return NewLocalSigner(key)
+}
+
+func TestClefSigner(t *testing.T) {
+	chainID := big.NewInt(31337)
+	local := newTestSigner(t)
+	clef := &fakeClef{signer: local}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", clef); err != nil {
+		t.Fatalf("Failed to register fake signer: %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	signer, err := NewClefSigner(context.Background(), httpServer.URL, common.Address{})
+	if err != nil {
+		t.Fatalf("Failed to connect to fake signer: %v", err)
+	}
+	assert.Equal(t, local.Address(), signer.Address())
+
+	signed, err := signer.SignTx(context.Background(), testTx(), chainID)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	assert.NoError(t, err)
+	assert.Equal(t, local.Address(), sender)
+	assert.Equal(t, uint64(7), signed.Nonce())
+
+	clef.tamper = true
+	_, err = signer.SignTx(context.Background(), testTx(), chainID)
+	assert.Error(t, err, "A transaction other than the requested one must be rejected")
+
+	_, err = NewClefSigner(context.Background(), httpServer.URL, common.HexToAddress("0x01"))
+	assert.Error(t, err, "An account the signer does not manage must be rejected")
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir := t.TempDir()
+	local := newTestSigner(t)
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    local.Address(),
+		PrivateKey: local.key,
+	}, "correct horse", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %v", err)
+	}
+
+	keystorePath := filepath.Join(dir, "key.json")
+	passphrasePath := filepath.Join(dir, "passphrase")
+	assert.NoError(t, os.WriteFile(keystorePath, keyJSON, 0600))
+	assert.NoError(t, os.WriteFile(passphrasePath, []byte("correct horse\n"), 0600))
+
+	signer, err := LoadKeystoreSigner(keystorePath, passphrasePath)
+	if err != nil {
+		t.Fatalf("Failed to load keystore: %v", err)
+	}
+	assert.Equal(t, local.Address(), signer.Address())
+
+	assert.NoError(t, os.WriteFile(passphrasePath, []byte("wrong"), 0600))
+	_, err = LoadKeystoreSigner(keystorePath, passphrasePath)
+	assert.Error(t, err)
+}
+
+func TestDevKeyRefusedOffDevChains(t *testing.T) {
+	t.Setenv("PRIVATE_KEY", "0x"+defaultDevPrivateKey)
+	profile := Profiles["mainnet"]
+
+	_, err := profile.signer(context.Background(), big.NewInt(1))
+	assert.Error(t, err)
+
+	local := Profiles["local"]
+	signer, err := local.signer(context.Background(), big.NewInt(31337))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultDevAddress(), signer.Address())
+}
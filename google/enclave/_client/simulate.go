@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Contracts whose custom errors can show up in revert data, the registry calls into the oracle and cert manager.
+var revertMetaData = []*bind.MetaData{
+	contracts.DKIMRegistryMetaData,
+	contracts.DKIMOracleMetaData,
+	contracts.CertManagerMetaData,
+}
+
+// RevertError is a call that reverted, with the reason decoded from the revert data where possible.
+type RevertError struct {
+	Reason string // revert string, panic reason or custom error with its arguments
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("execution reverted: %s", e.Reason)
+}
+
+// decodeRevert turns an RPC error carrying revert data into a RevertError, other errors are returned as they are.
+func decodeRevert(err error) error {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+
+	encoded, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, decodeErr := hexutil.Decode(encoded)
+	if decodeErr != nil || len(data) == 0 {
+		return err
+	}
+
+	return &RevertError{Reason: revertReason(data), Data: data}
+}
+
+// revertReason decodes Error(string), Panic(uint256) and the custom errors of the contract ABIs.
+func revertReason(data []byte) string {
+	if len(data) < 4 {
+		return fmt.Sprintf("invalid revert data %s", hexutil.Encode(data))
+	}
+
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+
+	for _, metaData := range revertMetaData {
+		parsed, err := metaData.GetAbi()
+		if err != nil {
+			continue
+		}
+		for _, customErr := range parsed.Errors {
+			if !bytes.Equal(customErr.ID[:4], data[:4]) {
+				continue
+			}
+			unpacked, err := customErr.Unpack(data)
+			if err != nil {
+				return fmt.Sprintf("%s (undecodable arguments %s)", customErr.Sig, hexutil.Encode(data[4:]))
+			}
+			return formatCustomError(customErr, unpacked)
+		}
+	}
+
+	return fmt.Sprintf("unknown error %s", hexutil.Encode(data))
+}
+
+func formatCustomError(customErr abi.Error, unpacked interface{}) string {
+	values, _ := unpacked.([]interface{})
+
+	args := make([]string, len(values))
+	for i, value := range values {
+		name := fmt.Sprintf("arg%d", i)
+		if i < len(customErr.Inputs) && customErr.Inputs[i].Name != "" {
+			name = customErr.Inputs[i].Name
+		}
+		if data, ok := value.([]byte); ok {
+			value = hexutil.Encode(data)
+		}
+		args[i] = fmt.Sprintf("%s=%v", name, value)
+	}
+	return fmt.Sprintf("%s(%s)", customErr.Name, strings.Join(args, ", "))
+}
+
+// preflight runs the exact transaction as eth_call and estimateGas against the pending state. It fails with a
+// RevertError if the transaction would revert, or if it needs more gas than it is given.
+func preflight(ctx context.Context, client TxBackend, from common.Address, tx *types.Transaction) (uint64, error) {
+	msg := ethereum.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}
+
+	if _, err := client.PendingCallContract(ctx, msg); err != nil {
+		return 0, decodeRevert(err)
+	}
+
+	msg.Gas = 0
+	estimate, err := client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, decodeRevert(err)
+	}
+	if estimate > tx.Gas() {
+		return estimate, fmt.Errorf("transaction needs %d gas but the limit is %d", estimate, tx.Gas())
+	}
+	return estimate, nil
+}
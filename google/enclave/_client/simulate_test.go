@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// The contracts revert with require strings, their generated ABIs declare no custom errors, so the tests add one.
+const customErrorABI = `[{"type":"error","name":"KeyAlreadyRegistered","inputs":[{"name":"domain","type":"string"},{"name":"publicKey","type":"bytes"}]}]`
+
+func withCustomErrors(t *testing.T) {
+	saved := revertMetaData
+	revertMetaData = append(append([]*bind.MetaData{}, saved...), &bind.MetaData{ABI: customErrorABI})
+	t.Cleanup(func() { revertMetaData = saved })
+}
+
+// revertData encodes a revert with the given error signature and arguments.
+func revertData(t *testing.T, sig string, types []string, values ...interface{}) []byte {
+	var args abi.Arguments
+	for _, name := range types {
+		typ, err := abi.NewType(name, "", nil)
+		assert.NoError(t, err)
+		args = append(args, abi.Argument{Type: typ})
+	}
+	packed, err := args.Pack(values...)
+	assert.NoError(t, err)
+	return append(crypto.Keccak256([]byte(sig))[:4], packed...)
+}
+
+func TestRevertReason(t *testing.T) {
+	withCustomErrors(t)
+
+	errorString := revertData(t, "Error(string)", []string{"string"}, "DKIMRegistry: invalid attestation")
+	custom := revertData(t, "KeyAlreadyRegistered(string,bytes)", []string{"string", "bytes"}, "gmail.com", []byte{0xca, 0xfe})
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"error string", errorString, "DKIMRegistry: invalid attestation"},
+		{"panic", revertData(t, "Panic(uint256)", []string{"uint256"}, big.NewInt(0x11)), "arithmetic underflow or overflow"},
+		{"custom error", custom, "KeyAlreadyRegistered(domain=gmail.com, publicKey=0xcafe)"},
+		{"custom error truncated", custom[:40], "KeyAlreadyRegistered(string,bytes) (undecodable arguments " + hexutil.Encode(custom[4:40]) + ")"},
+		{"unknown selector", []byte{0x12, 0x34, 0x56, 0x78, 0x9a}, "unknown error 0x123456789a"},
+		{"error string truncated", errorString[:36], "unknown error " + hexutil.Encode(errorString[:36])},
+		{"short", []byte{0x08, 0xc3}, "invalid revert data 0x08c3"},
+		{"empty", []byte{}, "invalid revert data 0x"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, revertReason(test.data))
+		})
+	}
+}
+
+// rpcRevert is a node error carrying revert data, as returned for eth_call and eth_estimateGas.
+type rpcRevert struct {
+	data string
+}
+
+func (e *rpcRevert) Error() string          { return "execution reverted" }
+func (e *rpcRevert) ErrorCode() int         { return 3 }
+func (e *rpcRevert) ErrorData() interface{} { return e.data }
+
+func TestDecodeRevert(t *testing.T) {
+	data := revertData(t, "Error(string)", []string{"string"}, "too late")
+
+	var revert *RevertError
+	assert.True(t, errors.As(decodeRevert(&rpcRevert{data: hexutil.Encode(data)}), &revert))
+	assert.Equal(t, "too late", revert.Reason)
+	assert.Equal(t, data, revert.Data)
+	assert.Equal(t, "execution reverted: too late", revert.Error())
+
+	// Errors without usable revert data are returned as they are
+	for _, err := range []error{
+		errors.New("connection refused"),
+		&rpcRevert{data: "0x"},
+		&rpcRevert{data: "not hex"},
+	} {
+		assert.Same(t, err, decodeRevert(err))
+	}
+}
+
+// fakeEth answers eth_call and eth_estimateGas.
+type fakeEth struct {
+	callErr     error
+	estimateErr error
+	estimate    uint64
+}
+
+func (f *fakeEth) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	return nil, f.callErr
+}
+
+func (f *fakeEth) EstimateGas(args map[string]interface{}) (hexutil.Uint64, error) {
+	return hexutil.Uint64(f.estimate), f.estimateErr
+}
+
+func TestPreflight(t *testing.T) {
+	reverted := &rpcRevert{data: hexutil.Encode(revertData(t, "Error(string)", []string{"string"}, "stale attestation"))}
+	tx := testTx() // 2,000,000 gas
+
+	tests := []struct {
+		name     string
+		eth      *fakeEth
+		estimate uint64
+		err      string
+	}{
+		{"passes", &fakeEth{estimate: 1_500_000}, 1_500_000, ""},
+		{"call reverts", &fakeEth{callErr: reverted}, 0, "execution reverted: stale attestation"},
+		{"estimate reverts", &fakeEth{estimateErr: reverted}, 0, "execution reverted: stale attestation"},
+		{"over the gas limit", &fakeEth{estimate: 2_500_000}, 2_500_000, "transaction needs 2500000 gas but the limit is 2000000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := rpc.NewServer()
+			defer server.Stop()
+			assert.NoError(t, server.RegisterName("eth", test.eth))
+			client := ethclient.NewClient(rpc.DialInProc(server))
+			defer client.Close()
+
+			estimate, err := preflight(context.Background(), client, common.HexToAddress("0x01"), tx)
+			assert.Equal(t, test.estimate, estimate)
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.err)
+			if strings.HasPrefix(test.err, "execution reverted") {
+				var revert *RevertError
+				assert.True(t, errors.As(err, &revert))
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	chainTargets   []*ChainTarget
+	skippedTargets []*SkippedTarget
+)
+
+// SkippedTarget is a chain that could not be reached at startup. The enclave publishes to the other chains without it.
+type SkippedTarget struct {
+	Profile *ChainProfile
+	Err     error
+}
+
+// ChainTarget is one chain the enclave publishes to. Every target has its own RPC connection through the host proxy
+// on the profile's vsock port, its own registry and its own submitter, so gas, nonces and failures never cross chains.
+type ChainTarget struct {
+	Profile   *ChainProfile
+	ChainID   *big.Int
+	Client    *ethclient.Client
+	Registry  *Registry
+	Submitter Submitter
+	// The profile enables signed updates and the registry implements them
+	SignedUpdates bool
+
+	mu sync.Mutex
+	// Enclave signing key the registry on this chain accepted an attestation for
+	boundKey common.Address
+}
+
+// NewChainTarget connects to the profile's chain through the proxy of host hostCID and checks it is the chain the profile was
+// written for. Submissions go to the host relayer on the profile's relayer port, or are signed in the enclave when the
+// relayer is disabled, which is only meant for local development.
+func NewChainTarget(ctx context.Context, hostCID uint32, profile *ChainProfile) (*ChainTarget, error) {
+	client, err := network.NewEthereumClientWithVsockTransport(hostCID, profile.RPCVsockPort, profile.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize ethereum client for %s: %v", profile.Name, err)
+	}
+
+	target := &ChainTarget{Profile: profile, Client: client}
+	target.ChainID, err = profile.Verify(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Connected to %s, chain ID: %s", profile.Name, target.ChainID.String())
+
+	target.Registry, err = NewRegistry(client, profile.RegistryAddress)
+	if err != nil {
+		return nil, err
+	}
+	if profile.SignedUpdates {
+		target.SignedUpdates, err = target.Registry.SupportsSignedUpdates(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		if !target.SignedUpdates {
+			log.Warnf("Signed updates are enabled for %s, but registry %s does not implement them, publishing attestations only",
+				profile.Name, profile.RegistryAddress.Hex())
+		}
+	}
+
+	if profile.Signer.RelayerPort == 0 {
+		auth, err := profile.transactor(ctx, target.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		queue, err := OpenSubmissionQueue(ctx, client, profile, target.Registry, auth)
+		if err != nil {
+			return nil, err
+		}
+		go queue.Recover()
+
+		target.Submitter = queue
+		return target, nil
+	}
+
+	// The relayer waits for confirmations before it answers
+	target.Submitter = NewRelayerClient(hostCID, profile.Signer.RelayerPort, profile.Confirmation.Timeout+time.Minute)
+	log.Infof("Submitting to %s through the host relayer on vsock port %d", profile.Name, profile.Signer.RelayerPort)
+	return target, nil
+}
+
+// InitChainTargets connects to every profile. A chain that cannot be reached is skipped and reported, so one chain
+// being down does not stop publishing to the others; it only fails if no chain could be reached. The first reachable
+// profile is the primary chain.
+func InitChainTargets(hostCID uint32, profiles []*ChainProfile) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no chain profiles")
+	}
+
+	targets := make([]*ChainTarget, 0, len(profiles))
+	var skipped []*SkippedTarget
+	for _, profile := range profiles {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		target, err := NewChainTarget(ctx, hostCID, profile)
+		cancel()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"severity": "alert",
+				"chain":    profile.Name,
+			}).Errorf("🚨 Skipping chain %s, it could not be reached: %v", profile.Name, err)
+			skipped = append(skipped, &SkippedTarget{Profile: profile, Err: err})
+			continue
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		chains := make([]string, len(skipped))
+		for i, target := range skipped {
+			chains[i] = fmt.Sprintf("%s: %v", target.Profile.Name, target.Err)
+		}
+		return fmt.Errorf("no chain could be reached: %s", strings.Join(chains, "; "))
+	}
+
+	chainTargets = targets
+	skippedTargets = skipped
+	return nil
+}
+
+// ChainTargets returns every chain the enclave publishes to, the primary chain first.
+func ChainTargets() []*ChainTarget {
+	return chainTargets
+}
+
+// SkippedTargets returns the chains that could not be reached at startup.
+func SkippedTargets() []*SkippedTarget {
+	return skippedTargets
+}
+
+func (t *ChainTarget) Name() string {
+	return t.Profile.Name
+}
+
+// BoundKey returns the enclave signing key the registry on this chain accepted an attestation for.
+func (t *ChainTarget) BoundKey() common.Address {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.boundKey
+}
+
+func (t *ChainTarget) setBoundKey(key common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.boundKey = key
+}
+
+// ChainResult is the result of one submission of a fan-out.
+type ChainResult struct {
+	Chain   string             `json:"chain"`
+	ChainID uint64             `json:"chain_id"`
+	Outcome *SubmissionOutcome `json:"outcome,omitempty"`
+	Err     error              `json:"-"`
+}
+
+// FanOutResult collects the results of submitting the same payload to several chains.
+type FanOutResult struct {
+	Results []*ChainResult
+}
+
+// Failed returns the results of the chains the submission did not succeed on.
+func (r *FanOutResult) Failed() []*ChainResult {
+	var failed []*ChainResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns a *PartialFailureError if the submission failed on any chain.
+func (r *FanOutResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &PartialFailureError{Failed: failed, Total: len(r.Results)}
+}
+
+// PartialFailureError reports the chains a fan-out failed on. The other chains were submitted to regardless.
+type PartialFailureError struct {
+	Failed []*ChainResult
+	Total  int
+}
+
+func (e *PartialFailureError) Error() string {
+	chains := make([]string, len(e.Failed))
+	for i, result := range e.Failed {
+		chains[i] = fmt.Sprintf("%s: %v", result.Chain, result.Err)
+	}
+	return fmt.Sprintf("submission failed on %d of %d chains: %s", len(e.Failed), e.Total, strings.Join(chains, "; "))
+}
+
+// fanOut runs submit for every target concurrently, each in its own span, and waits for all of them.
+func fanOut(ctx context.Context, kind string, targets []*ChainTarget, submit func(context.Context, *ChainTarget) (*SubmissionOutcome, error)) *FanOutResult {
+	result := &FanOutResult{Results: make([]*ChainResult, len(targets))}
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *ChainTarget) {
+			defer wg.Done()
+			ctx, span := tracing.Tracer.Start(ctx, "submit "+kind, trace.WithAttributes(
+				attribute.String("chain.name", target.Name()),
+				attribute.Int64("chain.id", target.ChainID.Int64()),
+			))
+			outcome, err := submit(ctx, target)
+			if outcome != nil {
+				span.SetAttributes(attribute.String("tx.hash", outcome.TxHash.Hex()), attribute.String("tx.status", string(outcome.Status)))
+			}
+			tracing.End(span, err)
+			result.Results[i] = &ChainResult{
+				Chain:   target.Name(),
+				ChainID: target.ChainID.Uint64(),
+				Outcome: outcome,
+				Err:     err,
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, chain := range result.Results {
+		fields := log.Fields{"chain": chain.Chain, "chain_id": chain.ChainID}
+		if chain.Outcome != nil {
+			fields["status"] = chain.Outcome.Status
+			fields["tx_hash"] = chain.Outcome.TxHash.Hex()
+			metrics.Submissions.WithLabelValues(chain.Chain, chain.Outcome.Kind, string(chain.Outcome.Status)).Inc()
+			metrics.GasUsed.WithLabelValues(chain.Chain, chain.Outcome.Kind).Add(float64(chain.Outcome.GasUsed))
+		}
+		if chain.Err != nil {
+			log.WithFields(fields).Errorf("Submission failed: %v", chain.Err)
+		} else {
+			log.WithFields(fields).Infof("Submission succeeded")
+		}
+	}
+	return result
+}
@@ -0,0 +1,403 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type SubmissionStatus string
+
+const (
+	StatusConfirmed SubmissionStatus = "confirmed" // mined successfully and buried under the confirmation depth
+	StatusReverted  SubmissionStatus = "reverted"  // mined and buried, but execution failed
+	StatusTimedOut  SubmissionStatus = "timed_out" // not confirmed before the submission timeout
+	StatusFailed    SubmissionStatus = "failed"    // could not be sent, or the nonce was taken by another transaction
+	StatusRejected  SubmissionStatus = "rejected"  // not broadcast because the pre-flight simulation failed
+)
+
+// TxAttempt is one broadcast of a submission. Replacements reuse the nonce with bumped fees.
+type TxAttempt struct {
+	Hash      common.Hash `json:"hash"`
+	GasTipCap *big.Int    `json:"gas_tip_cap"`
+	GasFeeCap *big.Int    `json:"gas_fee_cap"`
+	SentAt    time.Time   `json:"sent_at"`
+}
+
+// SubmissionOutcome is the final record of a submission, whatever happened to it.
+type SubmissionOutcome struct {
+	Kind     string           `json:"kind"` // attestation or signed_update
+	ChainID  uint64           `json:"chain_id"`
+	From     common.Address   `json:"from"`
+	Nonce    uint64           `json:"nonce"`
+	Status   SubmissionStatus `json:"status"`
+	Attempts []TxAttempt      `json:"attempts"`
+
+	// Set once one of the attempts was mined
+	TxHash            common.Hash `json:"tx_hash,omitempty"`
+	BlockNumber       uint64      `json:"block_number,omitempty"`
+	BlockHash         common.Hash `json:"block_hash,omitempty"`
+	GasEstimate       uint64      `json:"gas_estimate,omitempty"` // from the pre-flight simulation
+	GasUsed           uint64      `json:"gas_used,omitempty"`
+	EffectiveGasPrice *big.Int    `json:"effective_gas_price,omitempty"`
+	Confirmations     uint64      `json:"confirmations"`
+	Reorgs            int         `json:"reorgs"` // times the mined block dropped out of the canonical chain
+
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func (o *SubmissionOutcome) finish(status SubmissionStatus, err error) (*SubmissionOutcome, error) {
+	o.Status = status
+	o.FinishedAt = time.Now()
+	if err != nil {
+		o.Error = err.Error()
+	}
+
+	fields := log.Fields{
+		"kind":     o.Kind,
+		"chain_id": o.ChainID,
+		"nonce":    o.Nonce,
+		"status":   o.Status,
+		"attempts": len(o.Attempts),
+		"duration": o.FinishedAt.Sub(o.StartedAt).Round(time.Millisecond).String(),
+	}
+	if o.TxHash != (common.Hash{}) {
+		fields["tx_hash"] = o.TxHash.Hex()
+		fields["block"] = o.BlockNumber
+		fields["gas_used"] = o.GasUsed
+		fields["confirmations"] = o.Confirmations
+		fields["reorgs"] = o.Reorgs
+	}
+
+	if status == StatusConfirmed {
+		log.WithFields(fields).Infof("✅ Submission confirmed")
+		return o, nil
+	}
+	log.WithFields(fields).Errorf("❌ Submission %s: %v", status, err)
+	return o, err
+}
+
+// TxBackend is the part of the RPC client sending a transaction and following it to confirmation needs.
+// *ethclient.Client implements it.
+type TxBackend interface {
+	NonceSource
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+type txFees struct {
+	tipCap *big.Int
+	feeCap *big.Int
+}
+
+var errFeeCapReached = errors.New("fee cap reached")
+
+// estimateFees prices a dynamic fee transaction so it still gets included if the base fee doubles.
+func (g GasPolicy) estimateFees(ctx context.Context, client TxBackend) (*txFees, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %v", err)
+	}
+	if head.BaseFee == nil {
+		return nil, fmt.Errorf("chain does not support EIP-1559 dynamic fee transactions")
+	}
+
+	tipCap := g.MaxPriorityFeePerGas
+	if tipCap == nil {
+		tipCap, err = client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas tip cap: %v", err)
+		}
+	}
+
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tipCap)
+	if g.MaxFeePerGas != nil && feeCap.Cmp(g.MaxFeePerGas) > 0 {
+		if head.BaseFee.Cmp(g.MaxFeePerGas) > 0 {
+			return nil, fmt.Errorf("base fee %s is above the max fee per gas %s", head.BaseFee, g.MaxFeePerGas)
+		}
+		feeCap = new(big.Int).Set(g.MaxFeePerGas)
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap = new(big.Int).Set(feeCap)
+	}
+
+	return &txFees{tipCap: new(big.Int).Set(tipCap), feeCap: feeCap}, nil
+}
+
+// bumpFees raises both caps by BumpPercent, or to the current estimate if that is higher. Nodes only accept a
+// replacement if both caps went up by at least 10%.
+func (g GasPolicy) bumpFees(ctx context.Context, client TxBackend, previous *txFees) (*txFees, error) {
+	bump := func(value *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(value, big.NewInt(int64(100+g.BumpPercent)))
+		bumped.Div(bumped, big.NewInt(100))
+		if bumped.Cmp(value) <= 0 {
+			bumped.Add(value, big.NewInt(1))
+		}
+		return bumped
+	}
+
+	next := &txFees{tipCap: bump(previous.tipCap), feeCap: bump(previous.feeCap)}
+	if estimate, err := g.estimateFees(ctx, client); err == nil {
+		if estimate.tipCap.Cmp(next.tipCap) > 0 {
+			next.tipCap = estimate.tipCap
+		}
+		if estimate.feeCap.Cmp(next.feeCap) > 0 {
+			next.feeCap = estimate.feeCap
+		}
+	}
+
+	if g.MaxFeePerGas != nil && next.feeCap.Cmp(g.MaxFeePerGas) > 0 {
+		return nil, errFeeCapReached
+	}
+	if next.tipCap.Cmp(next.feeCap) > 0 {
+		next.tipCap = new(big.Int).Set(next.feeCap)
+	}
+	return next, nil
+}
+
+// txRequest is a transaction to send at a nonce handed out by the NonceManager.
+type txRequest struct {
+	kind     string
+	gasLimit uint64
+	nonce    uint64
+	build    func(auth *bind.TransactOpts) (*types.Transaction, error)
+
+	// Attempts broadcast before a restart. They are monitored and replaced like fresh ones instead of sending anew.
+	attempts []TxAttempt
+	// Called with the attempts before each broadcast, so the transaction is on record before it can be mined
+	record func(attempts []TxAttempt) error
+}
+
+// sendTransaction signs the transaction built by req as a dynamic fee transaction, replaces it with bumped fees while
+// it is stuck and waits until it is buried under the profile's confirmation depth.
+func sendTransaction(parent context.Context, client TxBackend, profile *ChainProfile, auth *bind.TransactOpts, req *txRequest) (*SubmissionOutcome, error) {
+	policy := profile.Confirmation
+	ctx, cancel := context.WithTimeout(parent, policy.Timeout)
+	defer cancel()
+
+	nonce := req.nonce
+	outcome := &SubmissionOutcome{
+		Kind:      req.kind,
+		From:      auth.From,
+		Nonce:     nonce,
+		Attempts:  append([]TxAttempt(nil), req.attempts...),
+		StartedAt: time.Now(),
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return outcome.finish(StatusFailed, fmt.Errorf("failed to get chain ID: %v", err))
+	}
+	outcome.ChainID = chainID.Uint64()
+
+	sign := func(fees *txFees) (*types.Transaction, error) {
+		opts := *auth
+		opts.Context = ctx
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		opts.GasLimit = req.gasLimit
+		opts.GasPrice = nil
+		opts.GasTipCap = fees.tipCap
+		opts.GasFeeCap = fees.feeCap
+		opts.NoSend = true
+
+		signedTx, err := req.build(&opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build transaction: %v", err)
+		}
+		return signedTx, nil
+	}
+
+	broadcast := func(fees *txFees) error {
+		signedTx, err := sign(fees)
+		if err != nil {
+			return err
+		}
+
+		attempts := append(outcome.Attempts, TxAttempt{
+			Hash:      signedTx.Hash(),
+			GasTipCap: fees.tipCap,
+			GasFeeCap: fees.feeCap,
+			SentAt:    time.Now(),
+		})
+		if req.record != nil {
+			if err := req.record(attempts); err != nil {
+				return fmt.Errorf("failed to record transaction: %v", err)
+			}
+		}
+
+		if err := client.SendTransaction(ctx, signedTx); err != nil {
+			if req.record != nil {
+				if err := req.record(outcome.Attempts); err != nil {
+					log.Errorf("Failed to drop unsent transaction %s from the record: %v", signedTx.Hash().Hex(), err)
+				}
+			}
+			return err
+		}
+
+		outcome.Attempts = attempts
+		log.Infof("Transaction sent! Hash: %s, nonce: %d, tip cap: %s, fee cap: %s",
+			signedTx.Hash().Hex(), nonce, fees.tipCap, fees.feeCap)
+		return nil
+	}
+
+	var fees *txFees
+	if len(outcome.Attempts) > 0 {
+		last := outcome.Attempts[len(outcome.Attempts)-1]
+		fees = &txFees{tipCap: last.GasTipCap, feeCap: last.GasFeeCap}
+		log.Infof("Resuming %s submission at nonce %d with %d earlier attempts", req.kind, nonce, len(outcome.Attempts))
+	} else {
+		fees, err = profile.Gas.estimateFees(ctx, client)
+		if err != nil {
+			return outcome.finish(StatusFailed, err)
+		}
+
+		// Simulate the exact transaction first, a revert would otherwise only show up after paying for it
+		signedTx, err := sign(fees)
+		if err != nil {
+			return outcome.finish(StatusFailed, err)
+		}
+		outcome.GasEstimate, err = preflight(ctx, client, auth.From, signedTx)
+		if err != nil {
+			return outcome.finish(StatusRejected, fmt.Errorf("refusing to broadcast: %v", err))
+		}
+		log.Infof("Pre-flight simulation passed, estimated gas: %d of %d", outcome.GasEstimate, req.gasLimit)
+
+		// Sign and send transaction
+		if err := broadcast(fees); err != nil {
+			return outcome.finish(StatusFailed, fmt.Errorf("failed to send transaction: %v", err))
+		}
+	}
+
+	log.Infof("Waiting for %d confirmations...", policy.Depth)
+
+	ticker := time.NewTicker(policy.PollInterval)
+	defer ticker.Stop()
+
+	var receipt *types.Receipt
+	for {
+		select {
+		case <-ctx.Done():
+			return outcome.finish(StatusTimedOut, fmt.Errorf("not confirmed after %s", policy.Timeout))
+		case <-ticker.C:
+		}
+
+		if receipt == nil {
+			receipt = findReceipt(ctx, client, outcome.Attempts)
+		}
+
+		if receipt == nil {
+			last := outcome.Attempts[len(outcome.Attempts)-1]
+			if time.Since(last.SentAt) < profile.Gas.StuckAfter {
+				continue
+			}
+
+			// None of our attempts was mined, but something used the nonce
+			mined, err := client.NonceAt(ctx, auth.From, nil)
+			if err == nil && mined > nonce {
+				if receipt = findReceipt(ctx, client, outcome.Attempts); receipt == nil {
+					return outcome.finish(StatusFailed, fmt.Errorf("nonce %d was used by another transaction", nonce))
+				}
+			} else if len(outcome.Attempts)-1 >= profile.Gas.MaxBumps {
+				continue
+			} else {
+				bumped, err := profile.Gas.bumpFees(ctx, client, fees)
+				if err != nil {
+					log.Warnf("Transaction %s pending for %s, not replacing it: %v",
+						last.Hash.Hex(), time.Since(last.SentAt).Round(time.Second), err)
+					continue
+				}
+
+				log.Warnf("Transaction %s pending for %s, replacing it with bumped fees",
+					last.Hash.Hex(), time.Since(last.SentAt).Round(time.Second))
+				if err := broadcast(bumped); err != nil {
+					if !isReplacementRace(err) {
+						log.Errorf("Failed to send replacement transaction: %v", err)
+					}
+					continue
+				}
+				fees = bumped
+				continue
+			}
+		}
+
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			log.Warnf("Failed to get block number: %v", err)
+			continue
+		}
+
+		// The receipt is only trustworthy while its block is still canonical
+		header, err := client.HeaderByNumber(ctx, receipt.BlockNumber)
+		if err != nil || header.Hash() != receipt.BlockHash {
+			log.Warnf("Block %d holding transaction %s was reorged out, waiting for it to be mined again",
+				receipt.BlockNumber.Uint64(), receipt.TxHash.Hex())
+			outcome.Reorgs++
+			receipt = nil
+			continue
+		}
+
+		outcome.TxHash = receipt.TxHash
+		outcome.BlockNumber = receipt.BlockNumber.Uint64()
+		outcome.BlockHash = receipt.BlockHash
+		outcome.GasUsed = receipt.GasUsed
+		outcome.EffectiveGasPrice = receipt.EffectiveGasPrice
+		outcome.Confirmations = 0
+		if head >= outcome.BlockNumber {
+			outcome.Confirmations = head - outcome.BlockNumber + 1
+		}
+		if outcome.Confirmations < policy.Depth {
+			continue
+		}
+
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			log.Errorf("❌ Transaction receipt: %v", receipt)
+			return outcome.finish(StatusReverted, fmt.Errorf("transaction execution failed"))
+		}
+		return outcome.finish(StatusConfirmed, nil)
+	}
+}
+
+// findReceipt returns the receipt of whichever attempt was mined, newest first.
+func findReceipt(ctx context.Context, client TxBackend, attempts []TxAttempt) *types.Receipt {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		receipt, err := client.TransactionReceipt(ctx, attempts[i].Hash)
+		if err == nil {
+			return receipt
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			log.Warnf("Failed to get receipt of %s: %v", attempts[i].Hash.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// isReplacementRace reports send errors that mean an earlier attempt is still in the pool or was just mined.
+func isReplacementRace(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, known := range []string{"already known", "replacement transaction underpriced", "nonce too low"} {
+		if strings.Contains(msg, known) {
+			log.Warnf("Replacement not accepted: %v", err)
+			return true
+		}
+	}
+	return false
+}
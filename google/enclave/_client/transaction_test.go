@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackend is a chain whose head moves one block every time it is asked for, and that only mines what the test
+// tells it to. onSend and onHead let a test react to a broadcast or to the chain moving.
+type fakeBackend struct {
+	mu       sync.Mutex
+	chainID  *big.Int
+	head     uint64
+	pending  uint64 // next nonce of the account, counting the mempool
+	mined    uint64 // next nonce of the account on chain
+	sent     []*types.Transaction
+	headers  map[uint64]*types.Header
+	receipts map[common.Hash]*types.Receipt
+
+	onSend func(f *fakeBackend, tx *types.Transaction) // called with f.mu held
+	onHead func(f *fakeBackend, head uint64)           // called with f.mu held
+}
+
+func newFakeBackend(head uint64, nonce uint64) *fakeBackend {
+	return &fakeBackend{
+		chainID:  big.NewInt(31337),
+		head:     head,
+		pending:  nonce,
+		mined:    nonce,
+		headers:  make(map[uint64]*types.Header),
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+}
+
+func (f *fakeBackend) header(number uint64) *types.Header {
+	if header, ok := f.headers[number]; ok {
+		return header
+	}
+	header := &types.Header{Number: new(big.Int).SetUint64(number), BaseFee: big.NewInt(1_000_000_000)}
+	f.headers[number] = header
+	return header
+}
+
+// mine includes the transaction in block number. Must be called with f.mu held.
+func (f *fakeBackend) mine(hash common.Hash, number uint64) {
+	f.receipts[hash] = &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      hash,
+		BlockNumber: new(big.Int).SetUint64(number),
+		BlockHash:   f.header(number).Hash(),
+		GasUsed:     21_000,
+	}
+}
+
+// reorg replaces block number, dropping the receipts of the transactions it held. Must be called with f.mu held.
+func (f *fakeBackend) reorg(number uint64) {
+	dropped := f.header(number).Hash()
+	f.headers[number] = &types.Header{Number: new(big.Int).SetUint64(number), BaseFee: big.NewInt(1_000_000_000), Extra: []byte("reorg")}
+	for hash, receipt := range f.receipts {
+		if receipt.BlockHash == dropped {
+			delete(f.receipts, hash)
+		}
+	}
+}
+
+func (f *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending, nil
+}
+
+func (f *fakeBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mined, nil
+}
+
+func (f *fakeBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return f.chainID, nil
+}
+
+func (f *fakeBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head++
+	if f.onHead != nil {
+		f.onHead(f, f.head)
+	}
+	return f.head, nil
+}
+
+func (f *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if number == nil {
+		return f.header(f.head), nil
+	}
+	return f.header(number.Uint64()), nil
+}
+
+func (f *fakeBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1_000_000_000), nil
+}
+
+func (f *fakeBackend) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 100_000, nil
+}
+
+func (f *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, tx)
+	if tx.Nonce() >= f.pending {
+		f.pending = tx.Nonce() + 1
+	}
+	if f.onSend != nil {
+		f.onSend(f, tx)
+	}
+	return nil
+}
+
+func (f *fakeBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if receipt, ok := f.receipts[txHash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func testProfile(depth uint64) *ChainProfile {
+	return &ChainProfile{
+		Name: "test",
+		Gas: GasPolicy{
+			AttestationGasLimit: 1_000_000,
+			BumpPercent:         20,
+			StuckAfter:          20 * time.Millisecond,
+			MaxBumps:            3,
+		},
+		Confirmation: ConfirmationPolicy{Depth: depth, Timeout: 5 * time.Second, PollInterval: 5 * time.Millisecond},
+		Queue:        QueuePolicy{Depth: 1},
+	}
+}
+
+// testRequest builds transactions signed by key with the nonce and fees sendTransaction picks.
+func testRequest(t *testing.T, key *ecdsa.PrivateKey, nonce uint64) (*bind.TransactOpts, *txRequest) {
+	auth := &bind.TransactOpts{From: crypto.PubkeyToAddress(key.PublicKey)}
+	to := common.HexToAddress("0xDc64a140Aa3E981100a9becA4E685f962f0cF6C9")
+	return auth, &txRequest{
+		kind:     KindAttestation,
+		gasLimit: 1_000_000,
+		nonce:    nonce,
+		build: func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			tx := types.NewTx(&types.DynamicFeeTx{
+				ChainID:   big.NewInt(31337),
+				Nonce:     opts.Nonce.Uint64(),
+				GasTipCap: opts.GasTipCap,
+				GasFeeCap: opts.GasFeeCap,
+				Gas:       opts.GasLimit,
+				To:        &to,
+			})
+			return types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(31337)), key)
+		},
+	}
+}
+
+func TestSendTransactionBumpsStuckFees(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	backend := newFakeBackend(100, 4)
+	// Only the replacement gets mined
+	backend.onSend = func(f *fakeBackend, tx *types.Transaction) {
+		if len(f.sent) == 2 {
+			f.mine(tx.Hash(), f.head)
+			f.mined = tx.Nonce() + 1
+		}
+	}
+
+	auth, req := testRequest(t, key, 4)
+	outcome, err := sendTransaction(context.Background(), backend, testProfile(1), auth, req)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusConfirmed, outcome.Status)
+	assert.Len(t, outcome.Attempts, 2)
+	assert.Equal(t, outcome.Attempts[1].Hash, outcome.TxHash)
+
+	first, second := outcome.Attempts[0], outcome.Attempts[1]
+	minimum := func(value *big.Int) *big.Int {
+		return new(big.Int).Div(new(big.Int).Mul(value, big.NewInt(120)), big.NewInt(100))
+	}
+	assert.True(t, second.GasTipCap.Cmp(minimum(first.GasTipCap)) >= 0, "tip cap %s not bumped from %s", second.GasTipCap, first.GasTipCap)
+	assert.True(t, second.GasFeeCap.Cmp(minimum(first.GasFeeCap)) >= 0, "fee cap %s not bumped from %s", second.GasFeeCap, first.GasFeeCap)
+	for _, tx := range backend.sent {
+		assert.Equal(t, uint64(4), tx.Nonce(), "replacements must reuse the nonce")
+	}
+}
+
+func TestSendTransactionWaitsOutReorg(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	backend := newFakeBackend(100, 0)
+	var minedAt uint64
+	backend.onSend = func(f *fakeBackend, tx *types.Transaction) {
+		minedAt = f.head
+		f.mine(tx.Hash(), minedAt)
+	}
+	// The block holding the transaction is replaced, and the transaction is mined again in a later block
+	backend.onHead = func(f *fakeBackend, head uint64) {
+		if head == minedAt+2 {
+			tx := f.sent[0]
+			f.reorg(minedAt)
+			f.mine(tx.Hash(), head)
+		}
+	}
+
+	auth, req := testRequest(t, key, 0)
+	outcome, err := sendTransaction(context.Background(), backend, testProfile(3), auth, req)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusConfirmed, outcome.Status)
+	assert.Equal(t, 1, outcome.Reorgs)
+	assert.Len(t, outcome.Attempts, 1)
+	assert.Equal(t, minedAt+2, outcome.BlockNumber)
+	assert.Equal(t, backend.header(minedAt+2).Hash(), outcome.BlockHash)
+	assert.GreaterOrEqual(t, outcome.Confirmations, uint64(3))
+}
+
+func TestSendTransactionNonceTakenByAnotherTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	backend := newFakeBackend(100, 2)
+	// Something else used the nonce, none of our attempts is ever mined
+	backend.onSend = func(f *fakeBackend, tx *types.Transaction) {
+		f.mined = tx.Nonce() + 1
+	}
+
+	auth, req := testRequest(t, key, 2)
+	outcome, err := sendTransaction(context.Background(), backend, testProfile(1), auth, req)
+	assert.EqualError(t, err, "nonce 2 was used by another transaction")
+	assert.Equal(t, StatusFailed, outcome.Status)
+	assert.Len(t, backend.sent, 1, "a used nonce must not be replaced")
+}
@@ -3,12 +3,12 @@ package attest
 import (
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
 	"github.com/EkamSinghPandher/Tee-Google/securelib"
-	"github.com/fxamacker/cbor/v2"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -18,16 +18,29 @@ func init() {
 }
 
 type AttestationPayload struct {
+	Version  uint64                       `json:"version"` // schema version the user data is encoded in
 	Provider string                       `json:"provider"`
+	Issuer   string                       `json:"issuer"`
 	JWKSKeys map[string]string            `json:"jwks_keys"` // kid -> base64 DER
 	DKIMKeys map[string]map[string]string `json:"dkim_keys"` // domain -> selector -> base64 DER
+
+	JWKSAlgorithms map[string]string                       `json:"jwks_algorithms"` // kid -> alg
+	JWKSEvidence   *network.Evidence                       `json:"jwks_evidence,omitempty"`
+	DKIMEvidence   map[string]map[string]*network.Evidence `json:"dkim_evidence,omitempty"` // domain -> selector -> evidence
+
+	ConfigHash []byte `json:"config_hash,omitempty"` // sha256 of the effective configuration the keys were fetched with
 }
 
 func PrepareAttestationPayload(googleKeys *network.GoogleKeys) (*AttestationPayload, error) {
 	payload := &AttestationPayload{
-		Provider: "google",
-		JWKSKeys: make(map[string]string),
-		DKIMKeys: make(map[string]map[string]string),
+		Version:        schema.LatestVersion,
+		Provider:       schema.DefaultProvider,
+		Issuer:         schema.DefaultIssuer,
+		JWKSKeys:       make(map[string]string),
+		DKIMKeys:       make(map[string]map[string]string),
+		JWKSAlgorithms: googleKeys.JWKSAlgorithms,
+		JWKSEvidence:   googleKeys.JWKSEvidence,
+		DKIMEvidence:   googleKeys.DKIMEvidence,
 	}
 
 	// Convert JWKS RSA keys to base64 DER
@@ -65,33 +78,163 @@ func PrepareAttestationPayload(googleKeys *network.GoogleKeys) (*AttestationPayl
 	return payload, nil
 }
 
-func GenerateMockAttestation(payload *AttestationPayload) ([]byte, error) {
+// Schema converts the payload into the latest typed schema, with keys as raw DER bytes.
+func (p *AttestationPayload) Schema() (*schema.PayloadV2, error) {
+	result := &schema.PayloadV2{
+		Version:    schema.Version2,
+		Provider:   p.Provider,
+		Issuer:     p.Issuer,
+		ConfigHash: p.ConfigHash,
+	}
+
+	if len(p.JWKSKeys) > 0 {
+		result.JWKS = &schema.JWKSSection{}
+		if p.JWKSEvidence != nil {
+			result.JWKS.Source = p.JWKSEvidence.Source
+			result.JWKS.FetchedAt = uint64(p.JWKSEvidence.FetchedAt.Unix())
+			result.JWKS.EvidenceHash = p.JWKSEvidence.Hash
+		}
+
+		for kid, key := range p.JWKSKeys {
+			derBytes, err := base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode JWKS key %s: %v", kid, err)
+			}
+			result.JWKS.Keys = append(result.JWKS.Keys, schema.JWKSKey{
+				Kid:       kid,
+				Algorithm: p.JWKSAlgorithms[kid],
+				PublicKey: derBytes,
+			})
+		}
+	}
+
+	if len(p.DKIMKeys) > 0 {
+		result.DKIM = &schema.DKIMSection{}
+
+		for domain, selectors := range p.DKIMKeys {
+			for selector, key := range selectors {
+				derBytes, err := base64.StdEncoding.DecodeString(key)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode DKIM key %s: %v", selector, err)
+				}
+
+				record := schema.DKIMRecord{
+					Domain:    domain,
+					Selector:  selector,
+					Algorithm: "rsa",
+					PublicKey: derBytes,
+				}
+				if evidence := p.DKIMEvidence[domain][selector]; evidence != nil {
+					record.EvidenceHash = evidence.Hash
+					// All selectors are looked up in the same pass, keep the latest lookup time
+					if fetchedAt := uint64(evidence.FetchedAt.Unix()); fetchedAt > result.DKIM.FetchedAt {
+						result.DKIM.FetchedAt = fetchedAt
+					}
+				}
+				result.DKIM.Records = append(result.DKIM.Records, record)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// UserData encodes the payload in the schema version it is configured for.
+func (p *AttestationPayload) UserData() ([]byte, error) {
+	typedPayload, err := p.Schema()
+	if err != nil {
+		return nil, err
+	}
 
-	// Convert to JSON for userData
-	userDataBytes, err := json.Marshal(payload)
+	// Canonical CBOR for userData so verifiers can recompute its hash
+	userDataBytes, err := schema.Encode(typedPayload, p.Version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal payload: %v", err)
 	}
+	return userDataBytes, nil
+}
+
+// FetchedAt returns when the oldest key in the payload was fetched, which is what limits its freshness. A payload
+// without evidence has no fetch time to vouch for and is an error.
+func (p *AttestationPayload) FetchedAt() (time.Time, error) {
+	var oldest time.Time
+	consider := func(evidence *network.Evidence) {
+		if evidence != nil && (oldest.IsZero() || evidence.FetchedAt.Before(oldest)) {
+			oldest = evidence.FetchedAt
+		}
+	}
+
+	consider(p.JWKSEvidence)
+	for _, selectors := range p.DKIMEvidence {
+		for _, evidence := range selectors {
+			consider(evidence)
+		}
+	}
+
+	if oldest.IsZero() {
+		return time.Time{}, fmt.Errorf("payload has no fetch evidence")
+	}
+	return oldest, nil
+}
+
+// BuildFreshnessNonce binds the attestation of the payload to the given block.
+func BuildFreshnessNonce(anchor *network.ChainAnchor, payload *AttestationPayload) ([]byte, error) {
+	fetchedAt, err := payload.FetchedAt()
+	if err != nil {
+		return nil, err
+	}
+	return schema.EncodeNonce(&schema.FreshnessNonce{
+		Version:     schema.NonceVersion,
+		ChainID:     anchor.ChainID.Uint64(),
+		BlockNumber: anchor.Number,
+		BlockHash:   anchor.Hash.Bytes(),
+		BlockTime:   anchor.Time,
+		FetchedAt:   uint64(fetchedAt.Unix()),
+		ConfigHash:  payload.ConfigHash,
+	})
+}
+
+// GenerateMockAttestation attests to the payload, nonce may be nil.
+func GenerateMockAttestation(payload *AttestationPayload, nonce []byte) ([]byte, error) {
+	userDataBytes, err := payload.UserData()
+	if err != nil {
+		return nil, err
+	}
 	// Generate mock attestation
 	manager := securelib.GetManager()
-	return manager.Attest(nil, userDataBytes)
+	return manager.Attest(nil, userDataBytes, nonce)
 }
 
-func GenerateMockDKIMCBORAttestation(payload *AttestationPayload) ([]byte, error) {
-	flattenedDKIM := make(map[string]string)
-	for domain, selectors := range payload.DKIMKeys {
-		for selector, key := range selectors {
-			flattenedKey := domain + ";" + selector
-			flattenedDKIM[flattenedKey] = key
-		}
+// GenerateMockDKIMCBORAttestation attests to the flattened DKIM keys. pubKey is the enclave signing key that will be
+// bound to the attestation and nonce the freshness nonce from BuildFreshnessNonce, both may be nil.
+func GenerateMockDKIMCBORAttestation(payload *AttestationPayload, pubKey []byte, nonce []byte) ([]byte, error) {
+	userDataBytes, err := DKIMUserData(payload)
+	if err != nil {
+		return nil, err
 	}
+	manager := securelib.GetManager()
+	return manager.Attest(pubKey, userDataBytes, nonce)
+}
 
-	userDataBytes, err := cbor.Marshal(flattenedDKIM)
+// GenerateMockKeyAttestation attests to a public key held by the enclave, with userData naming what the key is for.
+func GenerateMockKeyAttestation(pubKey []byte, userData []byte) ([]byte, error) {
+	manager := securelib.GetManager()
+	return manager.Attest(pubKey, userData, nil)
+}
+
+// DKIMUserData encodes the DKIM keys in the flattened version 0 {"domain;selector": key} CBOR map the DKIMRegistry
+// expects. The same bytes are used as attestation user_data and as the payload of signed key updates.
+func DKIMUserData(payload *AttestationPayload) ([]byte, error) {
+	typedPayload, err := payload.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	userDataBytes, err := schema.Encode(typedPayload, schema.Version0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal flattened DKIM keys to CBOR: %v", err)
 	}
-	manager := securelib.GetManager()
-	return manager.Attest(nil, userDataBytes)
+	return userDataBytes, nil
 }
 
 func ParseAttestation(attestation []byte) (*securelib.Doc, error) {
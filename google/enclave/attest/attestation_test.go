@@ -2,12 +2,12 @@ package attest
 
 import (
 	"crypto/rsa"
-	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
 	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,7 +34,7 @@ func TestInjectRealKeysIntoAttestation(t *testing.T) {
 		t.Fatalf("Failed to prepare payload: %v", err)
 	}
 
-	attestationBytes, err := GenerateMockAttestation(payload)
+	attestationBytes, err := GenerateMockAttestation(payload, nil)
 	if err != nil {
 		t.Fatalf("Failed to generate mock attestation: %v", err)
 	}
@@ -50,51 +50,70 @@ func TestInjectRealKeysIntoAttestation(t *testing.T) {
 		t.Fatalf("User data does not exist in payload")
 	}
 
-	// user_data is stored as JSON bytes, need to unmarshal it
+	// user_data is stored as canonical CBOR bytes
 	userDataBytes, ok := userData.([]byte)
 	if !ok {
 		t.Fatalf("User data is not bytes, it's %T", userData)
 	}
 
-	var keyData map[string]interface{}
-	err = json.Unmarshal(userDataBytes, &keyData)
+	keyData, version, err := schema.Decode(userDataBytes)
 	if err != nil {
-		t.Fatalf("Failed to unmarshal user data JSON: %v", err)
-	}
-
-	for k, v := range keyData {
-		switch k {
-		case "dkim_keys":
-			dkimMap := v.(map[string]interface{})
-			assert.Equal(t, len(googleKeys.DKIMKeys), len(dkimMap))
-
-			for domain, selectors := range dkimMap {
-				selectorMap := selectors.(map[string]interface{})
-				assert.Equal(t, len(googleKeys.DKIMKeys[domain]), len(selectorMap))
-
-				for _, keyString := range selectorMap {
-					assert.IsType(t, "", keyString, "DKIM key should be a base64 string")
-					keyStr := keyString.(string)
-					assert.Greater(t, len(keyStr), 10, "DKIM key string should be substantial")
-				}
-			}
-
-		case "jwks_keys":
-			jwksMap := v.(map[string]interface{})
-			assert.Equal(t, len(googleKeys.JWKSKeys), len(jwksMap))
-
-			for _, keyString := range jwksMap {
-				assert.IsType(t, "", keyString, "JWKS key should be a base64 string")
-				keyStr := keyString.(string)
-				assert.Greater(t, len(keyStr), 10, "JWKS key string should be substantial")
-			}
-
-		case "provider":
-			assert.Equal(t, "google", v)
-		}
+		t.Fatalf("Failed to decode user data CBOR: %v", err)
+	}
+
+	assert.Equal(t, uint64(schema.LatestVersion), version)
+	assert.Equal(t, "google", keyData.Provider)
+	assert.Equal(t, schema.DefaultIssuer, keyData.Issuer)
+
+	assert.Equal(t, len(googleKeys.DKIMKeys["example.com"]), len(keyData.DKIM.Records))
+	for _, record := range keyData.DKIM.Records {
+		assert.Contains(t, googleKeys.DKIMKeys, record.Domain)
+		assert.Greater(t, len(record.PublicKey), 10, "DKIM key should be substantial")
+	}
+
+	assert.Equal(t, len(googleKeys.JWKSKeys), len(keyData.JWKS.Keys))
+	for _, key := range keyData.JWKS.Keys {
+		assert.Greater(t, len(key.PublicKey), 10, "JWKS key should be substantial")
+	}
+}
+
+func TestCanonicalAttestationPayload(t *testing.T) {
+	payload := &AttestationPayload{
+		Provider: "google",
+		JWKSKeys: map[string]string{"b": "AQID", "a": "BAUG"},
+		DKIMKeys: map[string]map[string]string{
+			"gmail.com":   {"20230601": "AQID", "20161025": "BAUG"},
+			"example.com": {"1": "AQID"},
+		},
 	}
 
-	assert.Equal(t, len(keyData), 3, "Expected 3 items in keyData")
+	first, err := DKIMUserData(payload)
+	if err != nil {
+		t.Fatalf("Failed to encode DKIM user data: %v", err)
+	}
+	assert.NoError(t, schema.CheckCanonical(first))
+
+	typedPayload, err := payload.Schema()
+	if err != nil {
+		t.Fatalf("Failed to convert payload: %v", err)
+	}
+	encoded, err := schema.EncodeV2(typedPayload)
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+
+	// Map iteration order is random, the encoding must not be
+	for i := 0; i < 20; i++ {
+		again, err := DKIMUserData(payload)
+		assert.NoError(t, err)
+		assert.Equal(t, first, again)
+
+		typedPayload, err := payload.Schema()
+		assert.NoError(t, err)
+		againEncoded, err := schema.EncodeV2(typedPayload)
+		assert.NoError(t, err)
+		assert.Equal(t, encoded, againEncoded)
+	}
 }
 
 func parsePayload(payloadBytes []byte) (map[string]interface{}, error) {
@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// recordDomain separates audit record signatures from any other message the enclave key signs.
+var recordDomain = crypto.Keccak256Hash([]byte("TeeGoogle.AuditRecord"))
+
+// Record is what one refresh cycle fetched, attested and submitted. Records of an enclave run form a chain: each one
+// holds the digest of the previous one and is signed by the key of the session, so records cannot be changed, dropped
+// or reordered without breaking the chain. The key is generated for the session and attested before the first record,
+// so records can only come from the enclave.
+type Record struct {
+	Session  string      `json:"session"`  // random, new on every enclave start
+	Sequence uint64      `json:"sequence"` // from 0 within the session
+	Time     time.Time   `json:"time"`
+	PrevHash common.Hash `json:"prev_hash"` // digest of the previous record of the session, zero for the first one
+
+	Evidence        []Evidence    `json:"evidence"`
+	PayloadHash     hexutil.Bytes `json:"payload_hash,omitempty"` // sha256 of the attested user data
+	AttestationHash common.Hash   `json:"attestation_hash"`       // keccak256 of the attestation generated, if any
+	Transactions    []Transaction `json:"transactions"`
+	Error           string        `json:"error,omitempty"`
+
+	SigningKey         hexutil.Bytes `json:"signing_key"`               // uncompressed public key of the session key
+	KeyAttestationHash common.Hash   `json:"key_attestation_hash"`      // keccak256 of the attestation of the session key
+	KeyAttestation     hexutil.Bytes `json:"key_attestation,omitempty"` // that attestation, in the first record only
+	Signature          hexutil.Bytes `json:"signature"`
+}
+
+// Evidence is the hash of a raw response the keys were parsed from.
+type Evidence struct {
+	Source string        `json:"source"`
+	Hash   hexutil.Bytes `json:"hash"` // sha256
+}
+
+type Transaction struct {
+	Chain   string      `json:"chain"`
+	ChainID uint64      `json:"chain_id"`
+	Kind    string      `json:"kind"`
+	TxHash  common.Hash `json:"tx_hash"`
+	Status  string      `json:"status,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// Digest is what the enclave key signs and the next record links to: keccak256(domain || keccak256(json)), with the
+// JSON encoding of the record without its signature.
+func (r *Record) Digest() (common.Hash, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	encoded, err := json.Marshal(&unsigned)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode audit record: %v", err)
+	}
+	return crypto.Keccak256Hash(recordDomain.Bytes(), crypto.Keccak256(encoded)), nil
+}
+
+// Signer recovers the address that signed the record.
+func (r *Record) Signer() (common.Address, error) {
+	if len(r.Signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(r.Signature))
+	}
+	digest, err := r.Digest()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	signature := bytes.Clone(r.Signature)
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	pubKey, err := crypto.SigToPub(digest.Bytes(), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// Log chains and signs the records of one enclave run and keeps the latest ones until the host collects them.
+type Log struct {
+	mu          sync.Mutex
+	session     string
+	key         *identity.Key // never leaves the enclave and never changes within the session
+	attestation []byte        // binds key to the session
+	next        uint64
+	prevHash    common.Hash
+	records     []*Record
+	size        int
+}
+
+// NewLog starts a new session that keeps up to size records. The session gets its own signing key, attested with the
+// session as user data.
+func NewLog(size int) (*Log, error) {
+	key, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		return nil, err
+	}
+	session := uuid.NewString()
+	attestation, err := attest.GenerateMockKeyAttestation(key.PublicKey(), []byte(session))
+	if err != nil {
+		return nil, fmt.Errorf("failed to attest audit key: %v", err)
+	}
+	key.Bind(attestation)
+	return &Log{session: session, key: key, attestation: attestation, size: size}, nil
+}
+
+func (l *Log) Session() string {
+	return l.session
+}
+
+// Append links the record to the previous one, signs it with the session key and keeps it.
+func (l *Log) Append(record *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Session = l.session
+	record.Sequence = l.next
+	record.Time = time.Now().UTC()
+	record.PrevHash = l.prevHash
+
+	record.SigningKey = l.key.PublicKey()
+	record.KeyAttestationHash = l.key.AttestationHash()
+	record.KeyAttestation = nil
+	if record.Sequence == 0 {
+		record.KeyAttestation = l.attestation
+	}
+	record.Signature = nil
+
+	digest, err := record.Digest()
+	if err != nil {
+		return err
+	}
+	signed, err := l.key.Sign(digest)
+	if err != nil {
+		return err
+	}
+	record.Signature = signed.Signature
+
+	l.records = append(l.records, record)
+	if len(l.records) > l.size {
+		log.Warnf("Audit record %d dropped before the host collected it", l.records[0].Sequence)
+		l.records = l.records[len(l.records)-l.size:]
+	}
+	l.next++
+	l.prevHash = digest
+
+	log.WithFields(log.Fields{"session": record.Session, "sequence": record.Sequence, "digest": digest.Hex()}).
+		Infof("Audit record appended")
+	return nil
+}
+
+// Since returns the kept records of the session from sequence on. A caller that asks for another session gets the
+// current session from its start.
+func (l *Log) Since(session string, sequence uint64) []*Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if session != l.session {
+		sequence = 0
+	}
+	var records []*Record
+	for _, record := range l.records {
+		if record.Sequence >= sequence {
+			records = append(records, record)
+		}
+	}
+	return records
+}
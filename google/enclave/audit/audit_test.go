@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTrip stores and reloads the records the way the host does.
+func roundTrip(t *testing.T, records []*Record) []*Record {
+	var loaded []*Record
+	for _, record := range records {
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			t.Fatalf("Failed to encode record: %v", err)
+		}
+		var decoded Record
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("Failed to decode record: %v", err)
+		}
+		loaded = append(loaded, &decoded)
+	}
+	return loaded
+}
+
+// forge signs record with a key of the attacker's own, linked to prev if given.
+func forge(t *testing.T, record *Record, key *identity.Key, prev *Record) *Record {
+	if prev != nil {
+		digest, err := prev.Digest()
+		if err != nil {
+			t.Fatalf("Failed to hash record: %v", err)
+		}
+		record.Session, record.Sequence, record.PrevHash = prev.Session, prev.Sequence+1, digest
+	}
+	record.SigningKey = key.PublicKey()
+	record.KeyAttestationHash = key.AttestationHash()
+	digest, err := record.Digest()
+	if err != nil {
+		t.Fatalf("Failed to hash record: %v", err)
+	}
+	signed, err := key.Sign(digest)
+	if err != nil {
+		t.Fatalf("Failed to sign record: %v", err)
+	}
+	record.Signature = signed.Signature
+	return record
+}
+
+func newLog(t *testing.T, size int) *Log {
+	auditLog, err := NewLog(size)
+	if err != nil {
+		t.Fatalf("Failed to start audit log: %v", err)
+	}
+	return auditLog
+}
+
+func TestAuditTrail(t *testing.T) {
+	first := newLog(t, 2)
+	for i := 0; i < 3; i++ {
+		record := &Record{
+			Evidence:     []Evidence{{Source: "https://www.googleapis.com/oauth2/v3/certs", Hash: []byte{byte(i)}}},
+			Transactions: []Transaction{{Chain: "local", ChainID: 1337, Kind: "attestation", TxHash: common.Hash{byte(i)}}},
+		}
+		assert.NoError(t, first.Append(record))
+	}
+
+	// Only the latest records are kept for the host
+	kept := first.Since(first.Session(), 0)
+	assert.Len(t, kept, 2)
+	assert.Equal(t, uint64(1), kept[0].Sequence)
+	assert.Len(t, first.Since(first.Session(), 2), 1)
+	assert.Len(t, first.Since("other", 2), 2, "Another session must be served from its start")
+
+	second := newLog(t, 10)
+	assert.NoError(t, second.Append(&Record{Error: "fetch failed"}))
+
+	_, err := Verify(roundTrip(t, first.records))
+	assert.ErrorContains(t, err, "earlier records are missing")
+
+	// A full trail of the first session by collecting before the buffer overflows
+	full := newLog(t, 10)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, full.Append(&Record{PayloadHash: []byte{byte(i)}}))
+	}
+	trail := roundTrip(t, append(full.Since("", 0), second.Since("", 0)...))
+	report, err := Verify(trail)
+	if err != nil {
+		t.Fatalf("Failed to verify trail: %v", err)
+	}
+	assert.Equal(t, 4, report.Records)
+	assert.Equal(t, 2, report.Sessions)
+	assert.Equal(t, []common.Address{full.key.Address(), second.key.Address()}, report.Signers)
+	assert.Empty(t, trail[1].KeyAttestation, "Only the first record of a session carries the key attestation")
+
+	tampered := roundTrip(t, trail)
+	tampered[1].PayloadHash = []byte{0xff}
+	_, err = Verify(tampered)
+	assert.ErrorContains(t, err, "not signed by its signing key")
+
+	dropped := roundTrip(t, trail)
+	_, err = Verify(append(dropped[:1], dropped[2:]...))
+	assert.ErrorContains(t, err, "follows sequence 0")
+
+	interleaved := roundTrip(t, trail)
+	_, err = Verify([]*Record{interleaved[0], interleaved[3], interleaved[1]})
+	assert.ErrorContains(t, err, "continues after another session started")
+}
+
+func TestAuditTrailRejectsForeignSigners(t *testing.T) {
+	auditLog := newLog(t, 10)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, auditLog.Append(&Record{PayloadHash: []byte{byte(i)}}))
+	}
+	trail := roundTrip(t, auditLog.Since("", 0))
+	attacker, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	// Records appended to a session with another key, even one that claims the session attestation
+	appended := forge(t, &Record{Error: "forged"}, attacker, trail[1])
+	_, err = Verify(append(roundTrip(t, trail), appended))
+	assert.ErrorContains(t, err, "changes the signing key of its session")
+	claimed := forge(t, &Record{}, attacker, trail[1])
+	claimed.KeyAttestationHash = trail[0].KeyAttestationHash
+	_, err = Verify(append(roundTrip(t, trail), claimed))
+	assert.ErrorContains(t, err, "changes the signing key of its session")
+
+	// A session rewritten from its start with another key
+	unattested := forge(t, &Record{Session: trail[0].Session}, attacker, nil)
+	_, err = Verify([]*Record{unattested})
+	assert.ErrorContains(t, err, "signing key is not attested")
+
+	borrowed := forge(t, &Record{Session: trail[0].Session, KeyAttestation: trail[0].KeyAttestation}, attacker, nil)
+	borrowed.KeyAttestationHash = trail[0].KeyAttestationHash
+	_, err = Verify([]*Record{borrowed})
+	assert.ErrorContains(t, err, "signing key is not the attested key")
+
+	mismatched := roundTrip(t, trail)[0]
+	mismatched.KeyAttestationHash = common.Hash{1}
+	_, err = Verify([]*Record{mismatched})
+	assert.ErrorContains(t, err, "key attestation does not match its hash")
+
+	// The attestation of one session cannot start another
+	moved := roundTrip(t, trail)[0]
+	moved.Session = "other"
+	_, err = Verify([]*Record{moved})
+	assert.ErrorContains(t, err, "key attestation was made for another session")
+}
+
+// verifyEntries checks records and gap markers in the order given.
+func verifyEntries(entries ...interface{}) (*Report, error) {
+	verifier := NewVerifier()
+	for _, entry := range entries {
+		var err error
+		switch entry := entry.(type) {
+		case *Record:
+			err = verifier.Add(entry)
+		case *Gap:
+			err = verifier.Gap(entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return verifier.Report(), nil
+}
+
+func TestAuditTrailGaps(t *testing.T) {
+	first := newLog(t, 10)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, first.Append(&Record{PayloadHash: []byte{byte(i)}}))
+	}
+	second := newLog(t, 10)
+	assert.NoError(t, second.Append(&Record{Error: "fetch failed"}))
+	trail := roundTrip(t, first.Since("", 0))
+	next := roundTrip(t, second.Since("", 0))
+	session := first.Session()
+
+	// Records lost in the middle of a session
+	report, err := verifyEntries(trail[0], trail[1], &Gap{Session: session, From: 2, To: 3}, trail[4], next[0])
+	if err != nil {
+		t.Fatalf("Failed to verify trail with a gap: %v", err)
+	}
+	assert.Equal(t, 4, report.Records)
+	assert.Equal(t, 2, report.Sessions)
+	assert.Equal(t, []Gap{{Session: session, From: 2, To: 3}}, report.Gaps)
+	assert.Equal(t, []common.Address{first.key.Address(), second.key.Address()}, report.Signers)
+	assert.Empty(t, report.Unattested)
+
+	// The start of a session lost with its key attestation, the later sessions are still verified
+	report, err = verifyEntries(&Gap{Session: session, From: 0, To: 2}, trail[3], trail[4], next[0])
+	if err != nil {
+		t.Fatalf("Failed to verify trail with a lost session start: %v", err)
+	}
+	assert.Equal(t, 3, report.Records)
+	assert.Equal(t, []string{session}, report.Unattested)
+	assert.Equal(t, []common.Address{second.key.Address()}, report.Signers)
+
+	// A gap explains only the records it covers
+	_, err = verifyEntries(trail[0], &Gap{Session: session, From: 1, To: 2}, trail[4])
+	assert.ErrorContains(t, err, "does not follow gap")
+	_, err = verifyEntries(trail[0], &Gap{Session: session, From: 2, To: 3}, trail[4])
+	assert.ErrorContains(t, err, "follows sequence 0")
+	_, err = verifyEntries(trail[0], trail[1], &Gap{Session: session, From: 2, To: 3}, trail[4], trail[2])
+	assert.ErrorContains(t, err, "follows sequence 4")
+	_, err = verifyEntries(&Gap{Session: session, From: 1, To: 2}, trail[3])
+	assert.ErrorContains(t, err, "earlier records are missing")
+
+	// Records after a gap must still be signed by the session key
+	attacker, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	forged := forge(t, &Record{Error: "forged"}, attacker, trail[2])
+	_, err = verifyEntries(trail[0], trail[1], &Gap{Session: session, From: 2, To: 2}, forged)
+	assert.ErrorContains(t, err, "changes the signing key of its session")
+}
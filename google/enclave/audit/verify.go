@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Report summarises a verified audit trail.
+type Report struct {
+	Records    int
+	Sessions   int
+	Signers    []common.Address // one per attested session
+	Gaps       []Gap
+	Unattested []string // sessions whose first record, with the key attestation, was lost
+}
+
+// Gap marks records of a session, From to To inclusive, that the enclave dropped before the host collected them.
+type Gap struct {
+	Session string `json:"session"`
+	From    uint64 `json:"from"`
+	To      uint64 `json:"to"`
+}
+
+// Verifier checks records in the order they were stored. The first record of a session must carry an attestation of
+// the session key with the session as user data, and every record of the session must be signed by that key and
+// follow the previous record of its session. Every session must start at sequence 0 and end before the next one starts.
+// Records lost before they were stored must be marked with a Gap, the record after it is checked against the session
+// key but cannot link to the record before it.
+type Verifier struct {
+	report   Report
+	sessions map[string]bool
+	last     *Record
+	lastHash common.Hash
+	gap      *Gap // right before the next record
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{sessions: make(map[string]bool)}
+}
+
+// Gap notes that the records gap covers were lost. The next record must be the one after it.
+func (v *Verifier) Gap(gap *Gap) error {
+	if v.gap != nil {
+		return fmt.Errorf("gap %s/%d-%d follows another gap", gap.Session, gap.From, gap.To)
+	}
+	if gap.To < gap.From {
+		return fmt.Errorf("gap %s/%d-%d ends before it starts", gap.Session, gap.From, gap.To)
+	}
+	if v.last != nil && gap.Session == v.last.Session {
+		if gap.From != v.last.Sequence+1 {
+			return fmt.Errorf("gap %s/%d-%d follows sequence %d", gap.Session, gap.From, gap.To, v.last.Sequence)
+		}
+	} else if v.sessions[gap.Session] {
+		return fmt.Errorf("session %s continues after another session started", gap.Session)
+	} else if gap.From != 0 {
+		return fmt.Errorf("session %s starts with a gap at sequence %d, earlier records are missing", gap.Session, gap.From)
+	}
+
+	v.gap = gap
+	v.report.Gaps = append(v.report.Gaps, *gap)
+	return nil
+}
+
+// Add checks the next record.
+func (v *Verifier) Add(record *Record) error {
+	gap := v.gap
+	v.gap = nil
+	if gap != nil && (record.Session != gap.Session || record.Sequence != gap.To+1) {
+		return fmt.Errorf("record %s/%d does not follow gap %s/%d-%d", record.Session, record.Sequence, gap.Session, gap.From, gap.To)
+	}
+
+	if v.last != nil && record.Session == v.last.Session {
+		if gap == nil && record.Sequence != v.last.Sequence+1 {
+			return fmt.Errorf("record %s/%d follows sequence %d", record.Session, record.Sequence, v.last.Sequence)
+		}
+		if gap == nil && record.PrevHash != v.lastHash {
+			return fmt.Errorf("record %s/%d does not link to the previous record", record.Session, record.Sequence)
+		}
+		if !bytes.Equal(record.SigningKey, v.last.SigningKey) || record.KeyAttestationHash != v.last.KeyAttestationHash ||
+			len(record.KeyAttestation) > 0 {
+			return fmt.Errorf("record %s/%d changes the signing key of its session", record.Session, record.Sequence)
+		}
+	} else if gap != nil {
+		// The attestation of the session key was lost with the start of the session: the records can only be
+		// checked against each other
+		if len(record.KeyAttestation) > 0 {
+			return fmt.Errorf("record %s/%d carries a key attestation after the start of its session", record.Session, record.Sequence)
+		}
+	} else {
+		if v.sessions[record.Session] {
+			return fmt.Errorf("session %s continues after another session started", record.Session)
+		}
+		if record.Sequence != 0 || record.PrevHash != (common.Hash{}) {
+			return fmt.Errorf("session %s starts at sequence %d, earlier records are missing", record.Session, record.Sequence)
+		}
+		if err := verifyKeyAttestation(record); err != nil {
+			return fmt.Errorf("session %s: %v", record.Session, err)
+		}
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(record.SigningKey)
+	if err != nil {
+		return fmt.Errorf("record %s/%d has an invalid signing key: %v", record.Session, record.Sequence, err)
+	}
+	signer, err := record.Signer()
+	if err != nil {
+		return fmt.Errorf("record %s/%d: %v", record.Session, record.Sequence, err)
+	}
+	if signer != crypto.PubkeyToAddress(*pubKey) {
+		return fmt.Errorf("record %s/%d is not signed by its signing key", record.Session, record.Sequence)
+	}
+
+	digest, err := record.Digest()
+	if err != nil {
+		return err
+	}
+	if !v.sessions[record.Session] {
+		v.sessions[record.Session] = true
+		v.report.Sessions++
+		if record.Sequence == 0 {
+			v.report.Signers = append(v.report.Signers, signer)
+		} else {
+			v.report.Unattested = append(v.report.Unattested, record.Session)
+		}
+	}
+	v.last = record
+	v.lastHash = digest
+	v.report.Records++
+	return nil
+}
+
+// verifyKeyAttestation checks that the first record of a session carries a valid attestation of its signing key made
+// for the session.
+func verifyKeyAttestation(record *Record) error {
+	if len(record.KeyAttestation) == 0 {
+		return fmt.Errorf("signing key is not attested")
+	}
+	if crypto.Keccak256Hash(record.KeyAttestation) != record.KeyAttestationHash {
+		return fmt.Errorf("key attestation does not match its hash")
+	}
+	doc, err := attest.ParseAttestation(record.KeyAttestation)
+	if err != nil {
+		return fmt.Errorf("invalid key attestation: %v", err)
+	}
+	if !bytes.Equal(doc.PubKey, record.SigningKey) {
+		return fmt.Errorf("signing key is not the attested key")
+	}
+	if string(doc.UserData) != record.Session {
+		return fmt.Errorf("key attestation was made for another session")
+	}
+	return nil
+}
+
+// Report returns the summary of the records added so far. A gap not followed by a record yet is reported too.
+func (v *Verifier) Report() *Report {
+	report := v.report
+	return &report
+}
+
+// Verify checks a whole trail.
+func Verify(records []*Record) (*Report, error) {
+	verifier := NewVerifier()
+	for _, record := range records {
+		if err := verifier.Add(record); err != nil {
+			return nil, err
+		}
+	}
+	return verifier.Report(), nil
+}
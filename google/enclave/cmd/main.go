@@ -1,47 +1,366 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
 	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
 	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/daemon"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/logstream"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
 	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/tracing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Exit status codes
+const (
+	exitOK           = 0 // stopped by a signal
+	exitConfig       = 1 // invalid configuration or no chain could be reached at startup
+	exitDrainTimeout = 3 // stopped by a signal, but the refresh cycle in progress did not stop in time
+)
+
+// controlState is what the refresh cycles report to the control API
+var controlState = &control.State{}
+
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	log.Info("Starting google auth POC enclave service")
-	network.InitGoogleHttpsClientWithTLSVsockTransport(50001)
-	network.InitEthereumClientWithVsockTransport(50003)
 
-	keys, err := network.GetGoogleKeys()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Errorf("Error loading config: %v", err)
+		return exitConfig
+	}
 
+	// There is no console in the enclave, stream the logs to the host
+	if cfg.Logs.VsockPort != 0 {
+		streamer := logstream.Init(cfg.HostCID, cfg.Logs.VsockPort, cfg.Logs.BufferSize)
+		defer streamer.Close(cfg.ShutdownTimeout.Duration)
+	}
+
+	// Attested with every payload, so verifiers can tell which settings the keys were published with
+	configHash, err := cfg.Hash()
+	if err != nil {
+		log.Errorf("Error hashing config: %v", err)
+		return exitConfig
+	}
+	log.Infof("Effective config hash: %x", configHash)
+
+	// Spans leave the enclave through the host proxy to the collector
+	if cfg.Tracing.Endpoint != "" {
+		exportClient := &http.Client{Transport: &network.VsockHTTPRoundTripper{CID: cfg.HostCID, Port: cfg.Tracing.VsockPort}}
+		shutdownTracing, err := tracing.Init(context.Background(), "tee-google-enclave", cfg.Tracing.Endpoint, exportClient, cfg.Tracing.SampleRatio)
+		if err != nil {
+			log.Errorf("Error initializing tracing: %v", err)
+			return exitConfig
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Warnf("Error flushing traces: %v", err)
+			}
+		}()
+	}
+
+	chainProfiles, err := client.ProfilesFromConfig(cfg.Chains)
+	if err != nil {
+		log.Errorf("Error loading chain profiles: %v", err)
+		return exitConfig
+	}
+
+	network.InitGoogleHttpsClientWithTLSVsockTransport(cfg.HostCID, cfg.Google)
+	client.SetFreshnessWindow(client.FreshnessWindow{
+		MaxAge:    cfg.Freshness.MaxAge.Duration,
+		MaxBlocks: cfg.Freshness.MaxBlocks,
+	})
+
+	// Every chain is reached through its own host proxy and, unless disabled, its own host relayer
+	err = client.InitChainTargets(cfg.HostCID, chainProfiles)
+	if err != nil {
+		log.Errorf("Error initializing chain targets: %v", err)
+		return exitConfig
+	}
+
+	// The signing key only lives in enclave memory, a restart always requires a fresh attestation
+	signingKey, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		log.Errorf("Error generating enclave signing key: %v", err)
+		return exitConfig
+	}
+
+	// Every cycle leaves a signed record the host stores, whether or not it succeeded
+	auditLog, err := audit.NewLog(cfg.Audit.BufferSize)
+	if err != nil {
+		log.Errorf("Error starting audit log: %v", err)
+		return exitConfig
+	}
+	log.Infof("Audit session %s", auditLog.Session())
+
+	// Keep the keys current until asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	refresher := daemon.New(daemon.Options{
+		Interval:     cfg.Refresh.Interval.Duration,
+		Jitter:       cfg.Refresh.Jitter.Duration,
+		CycleTimeout: cfg.Refresh.CycleTimeout.Duration,
+		MinBackoff:   cfg.Refresh.MinBackoff.Duration,
+		MaxBackoff:   cfg.Refresh.MaxBackoff.Duration,
+		HistorySize:  cfg.Refresh.History,
+	}, func(ctx context.Context) error {
+		ctx, span := tracing.Tracer.Start(ctx, "refresh cycle")
+		record := &audit.Record{Evidence: []audit.Evidence{}, Transactions: []audit.Transaction{}}
+		err := refreshKeys(ctx, signingKey, configHash[:], record)
+		tracing.End(span, err)
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if err := auditLog.Append(record); err != nil {
+			log.Errorf("Error appending audit record: %v", err)
+		}
+		if err != nil {
+			return err
+		}
+		metrics.LastSuccess.SetToCurrentTime()
+		return nil
+	})
+	stopped := make(chan struct{})
+	go func() {
+		refresher.Run(ctx)
+		close(stopped)
+	}()
+
+	// The host reads the enclave's state and triggers refreshes over the control API
+	control.ShutdownTimeout = cfg.ShutdownTimeout.Duration
+	controlService := control.NewService(controlState, refresher, signingKey, auditLog, configHash[:])
+	controlStopped := make(chan struct{})
+	go func() {
+		if err := control.Serve(ctx, cfg.Control.VsockPort, cfg.Control.MaxRequestSize, controlService); err != nil {
+			log.Errorf("Error serving control API: %v", err)
+		}
+		close(controlStopped)
+	}()
+
+	<-ctx.Done()
+	log.Info("Stopping google auth POC enclave service")
+
+	// Submissions cannot be cancelled once handed to the relayer, don't wait for their confirmation forever
+	deadline := time.After(cfg.ShutdownTimeout.Duration)
+	for _, done := range []chan struct{}{stopped, controlStopped} {
+		select {
+		case <-done:
+		case <-deadline:
+			log.Errorf("Refresh cycle or control API did not stop within %s", cfg.ShutdownTimeout)
+			return exitDrainTimeout
+		}
+	}
+	return exitOK
+}
+
+// refreshKeys fetches the current Google keys and publishes them, noting what it did in record.
+func refreshKeys(ctx context.Context, signingKey *identity.Key, configHash []byte, record *audit.Record) error {
+	keys, err := network.GetGoogleKeys(ctx)
 	if err != nil {
 		log.Errorf("Error sending request through vsock with err: %v", err)
-		return
+		return err
 	}
+	recordEvidence(record, keys)
 
 	log.Infof("Successfully fetched keys: %+v", keys)
 
+	_, span := tracing.Tracer.Start(ctx, "PrepareAttestationPayload")
 	prepareAttestationPayload, err := attest.PrepareAttestationPayload(keys)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("Error preparing attestation payload: %v", err)
+		return err
 	}
+	prepareAttestationPayload.ConfigHash = configHash
 	log.Infof("Prepared attestation payload: %+v", prepareAttestationPayload)
+	controlState.SetPayload(prepareAttestationPayload)
+
+	err = publishKeys(ctx, signingKey, prepareAttestationPayload, record)
+	if err != nil {
+		log.Errorf("Error publishing keys: %v", err)
+		return err
+	}
+	return nil
+}
+
+// publishKeys submits the payload to every chain whose registry does not hold it yet. Chains with signed updates
+// enabled and implemented by their registry get a cheap signed update once they accepted an attestation for the signing
+// key, the others get a full attestation. The key is rotated once it has expired, which makes every chain need a new
+// attestation.
+func publishKeys(ctx context.Context, signingKey *identity.Key, payload *attest.AttestationPayload, record *audit.Record) error {
+	typedPayload, err := payload.Schema()
+	if err != nil {
+		return err
+	}
+	// The flattened DKIM keys are what attestations and signed updates carry on chain
+	userData, err := attest.DKIMUserData(payload)
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(userData)
+	record.PayloadHash = payloadHash[:]
+
+	// Don't pay for a submission that would not change anything on chain
+	decideCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	targets := client.TargetsToSubmit(decideCtx, typedPayload)
+	cancel()
+	if len(targets) == 0 {
+		return nil
+	}
 
-	attestation, err := attest.GenerateMockDKIMCBORAttestation(prepareAttestationPayload)
+	if signingKey.IsBound() && signingKey.NeedsReattestation(time.Now()) {
+		if err := signingKey.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	var attestTargets, updateTargets []*client.ChainTarget
+	for _, target := range targets {
+		if target.SignedUpdates && signingKey.IsBound() && target.BoundKey() == signingKey.Address() {
+			updateTargets = append(updateTargets, target)
+		} else {
+			attestTargets = append(attestTargets, target)
+		}
+	}
+
+	var errs []error
+	if len(attestTargets) > 0 {
+		if err := publishAttestation(ctx, signingKey, payload, attestTargets, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(updateTargets) > 0 {
+		if err := publishSignedUpdate(ctx, signingKey, payload, updateTargets, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishAttestation generates an attestation anchored to the first target's chain and submits it to every target.
+// The signing key is bound once any target accepted it.
+func publishAttestation(ctx context.Context, signingKey *identity.Key, payload *attest.AttestationPayload, targets []*client.ChainTarget, record *audit.Record) error {
+	// Bind the attestation to a recent block so it cannot be replayed once it is stale
+	anchorCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	anchor, err := network.GetChainAnchor(anchorCtx, targets[0].Client)
+	cancel()
+	if err != nil {
+		log.Errorf("Error fetching chain anchor: %v", err)
+		return err
+	}
+
+	nonce, err := attest.BuildFreshnessNonce(anchor, payload)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	_, span := tracing.Tracer.Start(ctx, "generate attestation")
+	attestation, err := attest.GenerateMockDKIMCBORAttestation(payload, signingKey.PublicKey(), nonce)
+	if err == nil {
+		span.SetAttributes(attribute.String("attestation.hash", crypto.Keccak256Hash(attestation).Hex()))
+	}
+	tracing.End(span, err)
+	metrics.AttestationDuration.Observe(time.Since(started).Seconds())
 	if err != nil {
 		log.Errorf("Error generating mock attestation: %v", err)
-		return
+		return err
 	}
 	log.Infof("Generated mock attestation: %d bytes", len(attestation))
+	record.AttestationHash = crypto.Keccak256Hash(attestation)
+	if err := controlState.SetAttestation(attestation, nonce, payload); err != nil {
+		return err
+	}
 
-	err = client.SubmitAttestationToBlockchain(attestation)
+	result, err := client.SubmitAttestationToBlockchain(ctx, targets, attestation)
+	controlState.SetSubmission(client.KindAttestation, result, err)
+	recordSubmission(record, client.KindAttestation, result)
+	if result != nil && len(result.Failed()) < len(result.Results) && !signingKey.IsBound() {
+		signingKey.Bind(attestation)
+	}
 	if err != nil {
 		log.Errorf("Error submitting attestation to blockchain: %v", err)
-		return
+		return err
+	}
+	return nil
+}
+
+// publishSignedUpdate signs the payload with the bound signing key and submits it to every target.
+func publishSignedUpdate(ctx context.Context, signingKey *identity.Key, payload *attest.AttestationPayload, targets []*client.ChainTarget, record *audit.Record) error {
+	userData, err := attest.DKIMUserData(payload)
+	if err != nil {
+		return err
+	}
+
+	update, err := signingKey.SignUpdate(userData)
+	if err != nil {
+		return err
 	}
 
-	for {
+	result, err := client.SubmitSignedUpdateToBlockchain(ctx, targets, update)
+	controlState.SetSubmission(client.KindSignedUpdate, result, err)
+	recordSubmission(record, client.KindSignedUpdate, result)
+	if err != nil {
+		log.Errorf("Error submitting signed update to blockchain: %v", err)
+		return err
+	}
+	return nil
+}
+
+// recordEvidence adds the hashes of the raw responses the keys were parsed from to record, JWKS first and the DKIM
+// records sorted by domain and selector.
+func recordEvidence(record *audit.Record, keys *network.GoogleKeys) {
+	if keys.JWKSEvidence != nil {
+		record.Evidence = append(record.Evidence, audit.Evidence{Source: keys.JWKSEvidence.Source, Hash: keys.JWKSEvidence.Hash})
+	}
+	for _, domain := range slices.Sorted(maps.Keys(keys.DKIMEvidence)) {
+		selectors := keys.DKIMEvidence[domain]
+		for _, selector := range slices.Sorted(maps.Keys(selectors)) {
+			evidence := selectors[selector]
+			record.Evidence = append(record.Evidence, audit.Evidence{Source: evidence.Source, Hash: evidence.Hash})
+		}
+	}
+}
 
+// recordSubmission adds the transactions of a fan-out to record.
+func recordSubmission(record *audit.Record, kind string, result *client.FanOutResult) {
+	if result == nil {
+		return
+	}
+	for _, chain := range result.Results {
+		transaction := audit.Transaction{Chain: chain.Chain, ChainID: chain.ChainID, Kind: kind}
+		if chain.Outcome != nil {
+			transaction.TxHash = chain.Outcome.TxHash
+			transaction.Status = string(chain.Outcome.Status)
+		}
+		if chain.Err != nil {
+			transaction.Error = chain.Err.Error()
+		}
+		record.Transactions = append(record.Transactions, transaction)
 	}
 }
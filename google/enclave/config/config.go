@@ -0,0 +1,509 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Defaults of the settings that are not part of a chain profile.
+const (
+	DefaultHostCID         = 3 // the parent instance as seen from a Nitro enclave
+	DefaultGoogleVsockPort = 50001
+	DefaultGoogleHost      = "www.googleapis.com"
+	DefaultGooglePort      = 443
+	DefaultJWKSURL         = "https://www.googleapis.com/oauth2/v3/certs"
+	DefaultDKIMDomain      = "gmail.com"
+	DefaultDKIMSelector    = "20230601"
+	DefaultChainProfile    = "local"
+
+	DefaultRefreshInterval = time.Hour
+	DefaultRefreshJitter   = 5 * time.Minute
+	DefaultCycleTimeout    = 45 * time.Minute // longer than the slowest confirmation timeout of the chain profiles
+	DefaultMinBackoff      = 30 * time.Second
+	DefaultMaxBackoff      = 30 * time.Minute
+	DefaultCycleHistory    = 100
+
+	DefaultMaxAttestationAge    = 10 * time.Minute
+	DefaultMaxAttestationBlocks = 50
+
+	DefaultShutdownTimeout = 10 * time.Second
+
+	DefaultEnclaveCID            = 16 // the first CID handed out by nitro-cli run-enclave
+	DefaultControlVsockPort      = 50007
+	DefaultControlMaxRequestSize = 64 << 10
+
+	DefaultAPIListenAddr   = ":8080"
+	DefaultAPIPollInterval = 30 * time.Second
+
+	DefaultLogVsockPort  = 50009
+	DefaultLogBufferSize = 1024
+	DefaultLogOutput     = "-"
+
+	DefaultAuditBufferSize   = 1000
+	DefaultAuditPath         = "audit-log.jsonl"
+	DefaultAuditPollInterval = 30 * time.Second
+
+	DefaultTracingVsockPort   = 50010
+	DefaultTracingSampleRatio = 1.0
+
+	DefaultHealthListenAddr        = ":8081"
+	DefaultHealthProbeInterval     = 30 * time.Second
+	DefaultHealthProbeTimeout      = 5 * time.Second
+	DefaultHealthMaxControlSilence = 2 * time.Minute
+	DefaultHealthMaxAttestationAge = 3 * time.Hour // an hourly refresh may back off and time out a few times first
+)
+
+// Config is the configuration shared by the host and the enclave. It is built from the defaults, then a JSON file,
+// then env vars, then flags, each overriding the previous one.
+type Config struct {
+	HostCID         uint32          `json:"host_cid"`
+	Google          GoogleConfig    `json:"google"`
+	Chains          []ChainConfig   `json:"chains"` // the first one is the primary chain
+	Refresh         RefreshConfig   `json:"refresh"`
+	Freshness       FreshnessConfig `json:"freshness"`
+	ShutdownTimeout Duration        `json:"shutdown_timeout"`
+	Control         ControlConfig   `json:"control"`
+	API             APIConfig       `json:"api"`
+	Logs            LogConfig       `json:"logs"`
+	Audit           AuditConfig     `json:"audit"`
+	Tracing         TracingConfig   `json:"tracing"`
+	Health          HealthConfig    `json:"health"`
+}
+
+// GoogleConfig says where the keys are fetched from. The host proxies VsockPort to Host:Port.
+type GoogleConfig struct {
+	VsockPort     uint32   `json:"vsock_port"`
+	Host          string   `json:"host"`
+	Port          uint32   `json:"port"`
+	JWKSURL       string   `json:"jwks_url"`
+	DKIMDomain    string   `json:"dkim_domain"`
+	DKIMSelectors []string `json:"dkim_selectors"`
+}
+
+// ChainConfig picks a built-in chain profile and overrides parts of it. Unset fields keep the profile's value.
+type ChainConfig struct {
+	Profile string `json:"profile"`
+
+	RPCURL          string `json:"rpc_url,omitempty"`
+	RPCVsockPort    uint32 `json:"rpc_vsock_port,omitempty"`
+	ChainID         uint64 `json:"chain_id,omitempty"`
+	RegistryAddress string `json:"registry_address,omitempty"`
+	OracleAddress   string `json:"oracle_address,omitempty"`
+	SignedUpdates   bool   `json:"signed_updates,omitempty"` // only used if the registry implements them
+
+	AttestationGasLimit  uint64 `json:"attestation_gas_limit,omitempty"`
+	SignedUpdateGasLimit uint64 `json:"signed_update_gas_limit,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"` // wei, decimal
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`          // wei, decimal
+	ConfirmationDepth    uint64 `json:"confirmation_depth,omitempty"`
+
+	SubmissionQueuePath *string `json:"submission_queue_path,omitempty"` // empty keeps the queue in memory
+	PipelineDepth       int     `json:"pipeline_depth,omitempty"`
+
+	ExternalSignerURL    string  `json:"external_signer_url,omitempty"`
+	SignerAddress        string  `json:"signer_address,omitempty"`
+	KeystorePath         string  `json:"keystore_path,omitempty"`
+	KeystorePasswordFile string  `json:"keystore_password_file,omitempty"`
+	RelayerVsockPort     *uint32 `json:"relayer_vsock_port,omitempty"` // 0 makes the enclave sign
+}
+
+// ControlConfig says where the enclave serves its control API. The host dials VsockPort of EnclaveCID.
+type ControlConfig struct {
+	EnclaveCID     uint32 `json:"enclave_cid"`
+	VsockPort      uint32 `json:"vsock_port"`
+	MaxRequestSize int    `json:"max_request_size"` // bytes
+}
+
+// APIConfig is the public HTTP API of the host, which republishes what the enclave attested.
+type APIConfig struct {
+	ListenAddr   string   `json:"listen_addr"`   // empty disables the API
+	PollInterval Duration `json:"poll_interval"` // how often the latest attestation is read from the enclave
+}
+
+// LogConfig says where the enclave streams its log entries. The host collects them on VsockPort and appends them to
+// Output as JSON lines.
+type LogConfig struct {
+	VsockPort  uint32 `json:"vsock_port"`  // 0 disables streaming
+	BufferSize int    `json:"buffer_size"` // entries the enclave keeps while the host is unavailable
+	Output     string `json:"output"`      // file path, - for stdout
+}
+
+// AuditConfig is the trail of signed records of every refresh cycle. The enclave keeps BufferSize records until the
+// host reads them over the control API and appends them to Path.
+type AuditConfig struct {
+	BufferSize   int      `json:"buffer_size"`
+	Path         string   `json:"path"`          // empty disables storing the records on the host
+	PollInterval Duration `json:"poll_interval"` // how often the host reads new records
+}
+
+// TracingConfig says where spans are exported over OTLP/HTTP. The host exports to Endpoint directly, the enclave
+// through the host proxy on VsockPort.
+type TracingConfig struct {
+	Endpoint    string  `json:"endpoint"` // host:port of the collector, empty disables tracing
+	VsockPort   uint32  `json:"vsock_port"`
+	SampleRatio float64 `json:"sample_ratio"` // of the refresh cycles traced, 0 to 1
+}
+
+// HealthConfig tunes the health checks the host always serves on ListenAddr, whether or not the API is enabled. The
+// host probes the upstreams and the enclave every ProbeInterval and stops being ready once the enclave has not answered
+// for MaxControlSilence or has not published attested keys for MaxAttestationAge.
+type HealthConfig struct {
+	ListenAddr        string   `json:"listen_addr"`
+	ProbeInterval     Duration `json:"probe_interval"`
+	ProbeTimeout      Duration `json:"probe_timeout"`
+	MaxControlSilence Duration `json:"max_control_silence"`
+	MaxAttestationAge Duration `json:"max_attestation_age"` // since the last successful refresh cycle
+}
+
+type RefreshConfig struct {
+	Interval     Duration `json:"interval"`
+	Jitter       Duration `json:"jitter"`
+	CycleTimeout Duration `json:"cycle_timeout"`
+	MinBackoff   Duration `json:"min_backoff"`
+	MaxBackoff   Duration `json:"max_backoff"`
+	History      int      `json:"history"`
+}
+
+type FreshnessConfig struct {
+	MaxAge    Duration `json:"max_age"`
+	MaxBlocks uint64   `json:"max_blocks"`
+}
+
+// Duration is a time.Duration written as a Go duration string in config files.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %v", err)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		HostCID: DefaultHostCID,
+		Google: GoogleConfig{
+			VsockPort:     DefaultGoogleVsockPort,
+			Host:          DefaultGoogleHost,
+			Port:          DefaultGooglePort,
+			JWKSURL:       DefaultJWKSURL,
+			DKIMDomain:    DefaultDKIMDomain,
+			DKIMSelectors: []string{DefaultDKIMSelector},
+		},
+		Chains: []ChainConfig{{Profile: DefaultChainProfile}},
+		Refresh: RefreshConfig{
+			Interval:     Duration{DefaultRefreshInterval},
+			Jitter:       Duration{DefaultRefreshJitter},
+			CycleTimeout: Duration{DefaultCycleTimeout},
+			MinBackoff:   Duration{DefaultMinBackoff},
+			MaxBackoff:   Duration{DefaultMaxBackoff},
+			History:      DefaultCycleHistory,
+		},
+		Freshness: FreshnessConfig{
+			MaxAge:    Duration{DefaultMaxAttestationAge},
+			MaxBlocks: DefaultMaxAttestationBlocks,
+		},
+		ShutdownTimeout: Duration{DefaultShutdownTimeout},
+		Control: ControlConfig{
+			EnclaveCID:     DefaultEnclaveCID,
+			VsockPort:      DefaultControlVsockPort,
+			MaxRequestSize: DefaultControlMaxRequestSize,
+		},
+		API: APIConfig{
+			ListenAddr:   DefaultAPIListenAddr,
+			PollInterval: Duration{DefaultAPIPollInterval},
+		},
+		Logs: LogConfig{
+			VsockPort:  DefaultLogVsockPort,
+			BufferSize: DefaultLogBufferSize,
+			Output:     DefaultLogOutput,
+		},
+		Audit: AuditConfig{
+			BufferSize:   DefaultAuditBufferSize,
+			Path:         DefaultAuditPath,
+			PollInterval: Duration{DefaultAuditPollInterval},
+		},
+		Tracing: TracingConfig{
+			VsockPort:   DefaultTracingVsockPort,
+			SampleRatio: DefaultTracingSampleRatio,
+		},
+		Health: HealthConfig{
+			ListenAddr:        DefaultHealthListenAddr,
+			ProbeInterval:     Duration{DefaultHealthProbeInterval},
+			ProbeTimeout:      Duration{DefaultHealthProbeTimeout},
+			MaxControlSilence: Duration{DefaultHealthMaxControlSilence},
+			MaxAttestationAge: Duration{DefaultHealthMaxAttestationAge},
+		},
+	}
+}
+
+// Load builds the configuration from the command line arguments (without the program name) and the environment. The
+// config file is taken from -config or CONFIG_FILE.
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "JSON config file")
+	chains := flags.String("chains", "", "comma separated chain profiles, overrides CHAIN_PROFILES")
+	hostCID := flags.Uint("host-cid", 0, "vsock CID of the host")
+	googleVsockPort := flags.Uint("google-vsock-port", 0, "vsock port of the Google proxy")
+	refreshInterval := flags.Duration("refresh-interval", 0, "time between refresh cycles")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 0, "time work in flight gets to finish on shutdown")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	chainsSelected := false
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "chains":
+			cfg.selectChains(strings.Split(*chains, ","))
+			chainsSelected = true
+		case "host-cid":
+			cfg.HostCID = uint32(*hostCID)
+		case "google-vsock-port":
+			cfg.Google.VsockPort = uint32(*googleVsockPort)
+		case "refresh-interval":
+			cfg.Refresh.Interval = Duration{*refreshInterval}
+		case "shutdown-timeout":
+			cfg.ShutdownTimeout = Duration{*shutdownTimeout}
+		}
+	})
+	if chainsSelected {
+		if err := cfg.applyChainEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// selectChains keeps the chains named, in that order, with the settings already configured for them.
+func (c *Config) selectChains(names []string) {
+	configured := make(map[string]ChainConfig, len(c.Chains))
+	for _, chain := range c.Chains {
+		configured[chain.Profile] = chain
+	}
+
+	c.Chains = nil
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		chain, ok := configured[name]
+		if !ok {
+			chain = ChainConfig{Profile: name}
+		}
+		c.Chains = append(c.Chains, chain)
+	}
+}
+
+func (c *Config) Validate() error {
+	if c.HostCID < 2 {
+		return fmt.Errorf("invalid host CID %d", c.HostCID)
+	}
+
+	if c.Google.VsockPort == 0 || c.Google.Port == 0 || c.Google.Host == "" {
+		return fmt.Errorf("incomplete Google proxy config")
+	}
+	jwksURL, err := url.Parse(c.Google.JWKSURL)
+	if err != nil || jwksURL.Scheme != "https" || jwksURL.Hostname() != c.Google.Host {
+		return fmt.Errorf("JWKS URL %q must be an https URL on %s", c.Google.JWKSURL, c.Google.Host)
+	}
+	if c.Google.DKIMDomain == "" || len(c.Google.DKIMSelectors) == 0 {
+		return fmt.Errorf("no DKIM domain or selectors")
+	}
+
+	if len(c.Chains) == 0 {
+		return fmt.Errorf("no chains")
+	}
+	seen := map[string]bool{}
+	for _, chain := range c.Chains {
+		if chain.Profile == "" {
+			return fmt.Errorf("chain without a profile name")
+		}
+		if seen[chain.Profile] {
+			return fmt.Errorf("chain profile %s listed twice", chain.Profile)
+		}
+		seen[chain.Profile] = true
+		for _, reserved := range []struct {
+			name string
+			port uint32
+		}{{"Google", c.Google.VsockPort}, {"control", c.Control.VsockPort}, {"log", c.Logs.VsockPort}, {"tracing", c.tracingVsockPort()}} {
+			if reserved.port == 0 {
+				continue
+			}
+			if chain.RPCVsockPort == reserved.port || (chain.RelayerVsockPort != nil && *chain.RelayerVsockPort == reserved.port) {
+				return fmt.Errorf("chain profile %s uses the %s vsock port %d", chain.Profile, reserved.name, reserved.port)
+			}
+		}
+	}
+
+	refresh := c.Refresh
+	if refresh.Interval.Duration <= 0 || refresh.CycleTimeout.Duration <= 0 {
+		return fmt.Errorf("refresh interval and cycle timeout must be positive")
+	}
+	if refresh.Jitter.Duration < 0 || refresh.Jitter.Duration >= refresh.Interval.Duration {
+		return fmt.Errorf("refresh jitter %s must be less than the interval %s", refresh.Jitter, refresh.Interval)
+	}
+	if refresh.MinBackoff.Duration <= 0 || refresh.MinBackoff.Duration > refresh.MaxBackoff.Duration {
+		return fmt.Errorf("backoff must be positive and the minimum not above the maximum")
+	}
+	if refresh.History < 1 {
+		return fmt.Errorf("cycle history must keep at least one cycle")
+	}
+
+	if c.Freshness.MaxAge.Duration <= 0 {
+		return fmt.Errorf("maximum attestation age must be positive")
+	}
+	if c.ShutdownTimeout.Duration <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive")
+	}
+
+	if c.Control.EnclaveCID < 3 || c.Control.EnclaveCID == c.HostCID {
+		return fmt.Errorf("invalid enclave CID %d", c.Control.EnclaveCID)
+	}
+	if c.Control.VsockPort == 0 || c.Control.VsockPort == c.Google.VsockPort {
+		return fmt.Errorf("invalid control vsock port %d", c.Control.VsockPort)
+	}
+	if c.Control.MaxRequestSize < 1 {
+		return fmt.Errorf("control request size limit must be positive")
+	}
+	if c.API.PollInterval.Duration <= 0 {
+		return fmt.Errorf("API poll interval must be positive")
+	}
+
+	if c.Logs.VsockPort != 0 && (c.Logs.VsockPort == c.Google.VsockPort || c.Logs.VsockPort == c.Control.VsockPort) {
+		return fmt.Errorf("log vsock port %d is already used", c.Logs.VsockPort)
+	}
+	if c.Logs.BufferSize < 1 || c.Logs.Output == "" {
+		return fmt.Errorf("log buffer size must be positive and the log output set")
+	}
+	if c.Audit.BufferSize < 1 || c.Audit.PollInterval.Duration <= 0 {
+		return fmt.Errorf("audit buffer size and poll interval must be positive")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio %v is not between 0 and 1", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Endpoint != "" {
+		if _, _, err := net.SplitHostPort(c.Tracing.Endpoint); err != nil {
+			return fmt.Errorf("invalid tracing endpoint %q: %v", c.Tracing.Endpoint, err)
+		}
+		if c.Tracing.VsockPort == 0 || c.Tracing.VsockPort == c.Google.VsockPort || c.Tracing.VsockPort == c.Control.VsockPort ||
+			c.Tracing.VsockPort == c.Logs.VsockPort {
+			return fmt.Errorf("invalid tracing vsock port %d", c.Tracing.VsockPort)
+		}
+	}
+
+	health := c.Health
+	if health.ListenAddr == "" || health.ListenAddr == c.API.ListenAddr {
+		return fmt.Errorf("health listen address %q must be set and differ from the API listen address", health.ListenAddr)
+	}
+	if health.ProbeInterval.Duration <= 0 || health.ProbeTimeout.Duration <= 0 || health.ProbeTimeout.Duration > health.ProbeInterval.Duration {
+		return fmt.Errorf("health probe interval and timeout must be positive and the timeout not above the interval")
+	}
+	if health.MaxControlSilence.Duration < health.ProbeInterval.Duration {
+		return fmt.Errorf("health control silence %s must be at least the probe interval %s", health.MaxControlSilence, health.ProbeInterval)
+	}
+	if health.MaxAttestationAge.Duration <= refresh.Interval.Duration {
+		return fmt.Errorf("health attestation age %s must be longer than the refresh interval %s", health.MaxAttestationAge, refresh.Interval)
+	}
+	return nil
+}
+
+// tracingVsockPort is the vsock port of the tracing proxy, or 0 if tracing is disabled.
+func (c *Config) tracingVsockPort() uint32 {
+	if c.Tracing.Endpoint == "" {
+		return 0
+	}
+	return c.Tracing.VsockPort
+}
+
+// enclaveConfig is the part of the configuration the enclave uses. Settings only the host uses, such as its listen
+// addresses, the log output, the audit log path and the poll intervals, are left out.
+type enclaveConfig struct {
+	HostCID               uint32          `json:"host_cid"`
+	Google                GoogleConfig    `json:"google"`
+	Chains                []ChainConfig   `json:"chains"`
+	Refresh               RefreshConfig   `json:"refresh"`
+	Freshness             FreshnessConfig `json:"freshness"`
+	ShutdownTimeout       Duration        `json:"shutdown_timeout"`
+	ControlVsockPort      uint32          `json:"control_vsock_port"`
+	ControlMaxRequestSize int             `json:"control_max_request_size"`
+	LogVsockPort          uint32          `json:"log_vsock_port"`
+	LogBufferSize         int             `json:"log_buffer_size"`
+	AuditBufferSize       int             `json:"audit_buffer_size"`
+	TracingEndpoint       string          `json:"tracing_endpoint"`
+	TracingVsockPort      uint32          `json:"tracing_vsock_port"`
+	TracingSampleRatio    float64         `json:"tracing_sample_ratio"`
+}
+
+// Hash is the sha256 of the JSON encoding of the settings the enclave uses. The enclave attests to it, so a verifier
+// can tell which settings the attested keys were fetched and published with, and changing a host setting does not
+// change it. Chain profiles are compiled into the enclave image, only the overrides are part of the configuration.
+func (c *Config) Hash() ([32]byte, error) {
+	encoded, err := json.Marshal(&enclaveConfig{
+		HostCID:               c.HostCID,
+		Google:                c.Google,
+		Chains:                c.Chains,
+		Refresh:               c.Refresh,
+		Freshness:             c.Freshness,
+		ShutdownTimeout:       c.ShutdownTimeout,
+		ControlVsockPort:      c.Control.VsockPort,
+		ControlMaxRequestSize: c.Control.MaxRequestSize,
+		LogVsockPort:          c.Logs.VsockPort,
+		LogBufferSize:         c.Logs.BufferSize,
+		AuditBufferSize:       c.Audit.BufferSize,
+		TracingEndpoint:       c.Tracing.Endpoint,
+		TracingVsockPort:      c.Tracing.VsockPort,
+		TracingSampleRatio:    c.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to encode config: %v", err)
+	}
+	return sha256.Sum256(encoded), nil
+}
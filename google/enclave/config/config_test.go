@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `{
+		"host_cid": 5,
+		"chains": [{"profile": "testnet", "rpc_url": "https://file.example.org"}, {"profile": "local"}],
+		"refresh": {"interval": "2h", "jitter": "1m", "cycle_timeout": "10m", "min_backoff": "1s", "max_backoff": "1m", "history": 10}
+	}`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("HOST_CID", "6")
+	t.Setenv("LOCAL_RPC_URL", "http://127.0.0.1:9545")
+
+	cfg, err := Load([]string{"-host-cid", "7"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	assert.Equal(t, uint32(7), cfg.HostCID, "Flags must override env and file")
+	assert.Equal(t, 2*time.Hour, cfg.Refresh.Interval.Duration)
+	assert.Equal(t, DefaultGoogleVsockPort, int(cfg.Google.VsockPort), "Unset values must keep their default")
+
+	assert.Len(t, cfg.Chains, 2)
+	assert.Equal(t, "https://file.example.org", cfg.Chains[0].RPCURL)
+	assert.Equal(t, "http://127.0.0.1:9545", cfg.Chains[1].RPCURL, "A prefixed env var must only apply to its chain")
+
+	// Selecting chains keeps what the file configured for them
+	cfg, err = Load([]string{"-chains", "testnet"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	assert.Len(t, cfg.Chains, 1)
+	assert.Equal(t, "https://file.example.org", cfg.Chains[0].RPCURL)
+}
+
+func TestLoadChainsFlagKeepsEnv(t *testing.T) {
+	t.Setenv("DKIM_REGISTRY_ADDRESS", "0x1111111111111111111111111111111111111111")
+	t.Setenv("MAINNET_RPC_URL", "https://mainnet.example.org")
+	t.Setenv("RELAYER_VSOCK_PORT", "0")
+	t.Setenv("TESTNET_SIGNED_UPDATES", "true")
+
+	// Neither chain was configured before the flag picked it
+	cfg, err := Load([]string{"-chains", "testnet,mainnet"})
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	assert.Len(t, cfg.Chains, 2)
+	for _, chain := range cfg.Chains {
+		assert.Equal(t, "0x1111111111111111111111111111111111111111", chain.RegistryAddress, chain.Profile)
+		assert.Equal(t, new(uint32), chain.RelayerVsockPort, chain.Profile)
+	}
+	assert.True(t, cfg.Chains[0].SignedUpdates)
+	assert.False(t, cfg.Chains[1].SignedUpdates)
+	assert.Empty(t, cfg.Chains[0].RPCURL)
+	assert.Equal(t, "https://mainnet.example.org", cfg.Chains[1].RPCURL)
+
+	// The flag gives the same chains as the env var
+	t.Setenv("CHAIN_PROFILES", "testnet,mainnet")
+	fromEnv, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	assert.Equal(t, fromEnv.Chains, cfg.Chains)
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	_, err := Load([]string{"-config", writeConfig(t, `{"chain": []}`)})
+	assert.Error(t, err, "Unknown fields must be rejected")
+
+	_, err = Load([]string{"-config", writeConfig(t, `{"refresh": {"interval": 3600}}`)})
+	assert.Error(t, err, "Durations must be strings")
+
+	t.Setenv("CHAIN_PROFILES", "local,local")
+	_, err = Load(nil)
+	assert.Error(t, err, "A chain listed twice must be rejected")
+
+	t.Setenv("CHAIN_PROFILES", "")
+	t.Setenv("REFRESH_JITTER", "2h")
+	_, err = Load(nil)
+	assert.Error(t, err, "Jitter above the interval must be rejected")
+
+	t.Setenv("REFRESH_JITTER", "")
+	t.Setenv("CONTROL_VSOCK_PORT", "50001")
+	_, err = Load(nil)
+	assert.Error(t, err, "The control API must not share the Google vsock port")
+
+	t.Setenv("CONTROL_VSOCK_PORT", "")
+	t.Setenv("HEALTH_MAX_ATTESTATION_AGE", "30m")
+	_, err = Load(nil)
+	assert.Error(t, err, "Attestations must not turn stale before the next refresh is due")
+}
+
+func TestHash(t *testing.T) {
+	a, err := Default().Hash()
+	assert.NoError(t, err)
+	b, err := Default().Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	for name, change := range map[string]func(c *Config){
+		"rpc url":          func(c *Config) { c.Chains[0].RPCURL = "http://127.0.0.1:9545" },
+		"refresh interval": func(c *Config) { c.Refresh.Interval = Duration{2 * time.Hour} },
+		"log vsock port":   func(c *Config) { c.Logs.VsockPort = 50019 },
+		"audit buffer":     func(c *Config) { c.Audit.BufferSize = 10 },
+		"tracing":          func(c *Config) { c.Tracing.Endpoint = "collector:4318" },
+	} {
+		changed := Default()
+		change(changed)
+		c, err := changed.Hash()
+		assert.NoError(t, err)
+		assert.NotEqual(t, a, c, "%s is an enclave setting", name)
+	}
+
+	// Host settings are not attested
+	for name, change := range map[string]func(c *Config){
+		"api":         func(c *Config) { c.API = APIConfig{ListenAddr: ":9090", PollInterval: Duration{time.Minute}} },
+		"health":      func(c *Config) { c.Health.ListenAddr, c.Health.ProbeInterval = ":9091", Duration{time.Minute} },
+		"log output":  func(c *Config) { c.Logs.Output = "enclave.log" },
+		"audit log":   func(c *Config) { c.Audit.Path, c.Audit.PollInterval = "other.jsonl", Duration{time.Minute} },
+		"enclave cid": func(c *Config) { c.Control.EnclaveCID = 17 },
+	} {
+		changed := Default()
+		change(changed)
+		c, err := changed.Hash()
+		assert.NoError(t, err)
+		assert.Equal(t, a, c, "%s is a host setting", name)
+	}
+}
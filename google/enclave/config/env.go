@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv applies the env var overrides. CHAIN_PROFILES (comma separated) or CHAIN_PROFILE picks the chains. The
+// per-chain variables apply to every chain, or to one chain only when prefixed with its profile name, e.g.
+// TESTNET_RPC_URL.
+func (c *Config) applyEnv() error {
+	if err := envUint32("HOST_CID", &c.HostCID); err != nil {
+		return err
+	}
+	if err := envUint32("GOOGLE_VSOCK_PORT", &c.Google.VsockPort); err != nil {
+		return err
+	}
+	if err := envUint32("GOOGLE_PORT", &c.Google.Port); err != nil {
+		return err
+	}
+	envString("GOOGLE_HOST", &c.Google.Host)
+	envString("GOOGLE_JWKS_URL", &c.Google.JWKSURL)
+	envString("DKIM_DOMAIN", &c.Google.DKIMDomain)
+	if value := os.Getenv("DKIM_SELECTORS"); value != "" {
+		c.Google.DKIMSelectors = strings.Split(value, ",")
+	}
+
+	for env, target := range map[string]*Duration{
+		"REFRESH_INTERVAL":    &c.Refresh.Interval,
+		"REFRESH_JITTER":      &c.Refresh.Jitter,
+		"CYCLE_TIMEOUT":       &c.Refresh.CycleTimeout,
+		"MIN_BACKOFF":         &c.Refresh.MinBackoff,
+		"MAX_BACKOFF":         &c.Refresh.MaxBackoff,
+		"MAX_ATTESTATION_AGE": &c.Freshness.MaxAge,
+		"SHUTDOWN_TIMEOUT":    &c.ShutdownTimeout,
+		"API_POLL_INTERVAL":   &c.API.PollInterval,
+		"AUDIT_POLL_INTERVAL": &c.Audit.PollInterval,
+
+		"HEALTH_PROBE_INTERVAL":      &c.Health.ProbeInterval,
+		"HEALTH_PROBE_TIMEOUT":       &c.Health.ProbeTimeout,
+		"HEALTH_MAX_CONTROL_SILENCE": &c.Health.MaxControlSilence,
+		"HEALTH_MAX_ATTESTATION_AGE": &c.Health.MaxAttestationAge,
+	} {
+		if value := os.Getenv(env); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %v", env, value, err)
+			}
+			*target = Duration{duration}
+		}
+	}
+
+	if value := os.Getenv("CYCLE_HISTORY"); value != "" {
+		history, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid CYCLE_HISTORY %q: %v", value, err)
+		}
+		c.Refresh.History = history
+	}
+	if err := envUint64("MAX_ATTESTATION_BLOCKS", &c.Freshness.MaxBlocks); err != nil {
+		return err
+	}
+
+	if err := envUint32("ENCLAVE_CID", &c.Control.EnclaveCID); err != nil {
+		return err
+	}
+	if err := envUint32("CONTROL_VSOCK_PORT", &c.Control.VsockPort); err != nil {
+		return err
+	}
+	if err := envUint32("LOG_VSOCK_PORT", &c.Logs.VsockPort); err != nil {
+		return err
+	}
+	envString("LOG_OUTPUT", &c.Logs.Output)
+	if value := os.Getenv("LOG_BUFFER_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_BUFFER_SIZE %q: %v", value, err)
+		}
+		c.Logs.BufferSize = size
+	}
+	if value := os.Getenv("AUDIT_BUFFER_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid AUDIT_BUFFER_SIZE %q: %v", value, err)
+		}
+		c.Audit.BufferSize = size
+	}
+	if value, ok := os.LookupEnv("AUDIT_LOG_PATH"); ok {
+		c.Audit.Path = value
+	}
+	envString("TRACING_ENDPOINT", &c.Tracing.Endpoint)
+	if err := envUint32("TRACING_VSOCK_PORT", &c.Tracing.VsockPort); err != nil {
+		return err
+	}
+	if value := os.Getenv("TRACING_SAMPLE_RATIO"); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q: %v", value, err)
+		}
+		c.Tracing.SampleRatio = ratio
+	}
+	if value, ok := os.LookupEnv("API_LISTEN_ADDR"); ok {
+		c.API.ListenAddr = value
+	}
+	envString("HEALTH_LISTEN_ADDR", &c.Health.ListenAddr)
+	if value := os.Getenv("CONTROL_MAX_REQUEST_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid CONTROL_MAX_REQUEST_SIZE %q: %v", value, err)
+		}
+		c.Control.MaxRequestSize = size
+	}
+
+	if value := os.Getenv("CHAIN_PROFILES"); value != "" {
+		c.selectChains(strings.Split(value, ","))
+	} else if value := os.Getenv("CHAIN_PROFILE"); value != "" {
+		c.selectChains([]string{value})
+	}
+	return c.applyChainEnv()
+}
+
+// applyChainEnv applies the per-chain env var overrides to every chain. Applying them again has no further effect, so
+// it also runs after -chains selected chains the env did not know about.
+func (c *Config) applyChainEnv() error {
+	for i := range c.Chains {
+		if err := c.Chains[i].applyEnv(); err != nil {
+			return fmt.Errorf("chain profile %s: %v", c.Chains[i].Profile, err)
+		}
+	}
+	return nil
+}
+
+func (c *ChainConfig) applyEnv() error {
+	// NAME_VAR overrides VAR for the chain, so several chains can be configured side by side
+	prefix := strings.ToUpper(c.Profile) + "_"
+	lookupEnv := func(env string) (string, bool) {
+		if value, ok := os.LookupEnv(prefix + env); ok {
+			return value, true
+		}
+		return os.LookupEnv(env)
+	}
+	getenv := func(env string) string {
+		value, _ := lookupEnv(env)
+		return value
+	}
+
+	for env, target := range map[string]*string{
+		"RPC_URL":                  &c.RPCURL,
+		"DKIM_REGISTRY_ADDRESS":    &c.RegistryAddress,
+		"DKIM_ORACLE_ADDRESS":      &c.OracleAddress,
+		"SIGNER_ADDRESS":           &c.SignerAddress,
+		"MAX_PRIORITY_FEE_PER_GAS": &c.MaxPriorityFeePerGas,
+		"MAX_FEE_PER_GAS":          &c.MaxFeePerGas,
+		"EXTERNAL_SIGNER_URL":      &c.ExternalSignerURL,
+		"KEYSTORE_PATH":            &c.KeystorePath,
+		"KEYSTORE_PASSWORD_FILE":   &c.KeystorePasswordFile,
+	} {
+		if value := getenv(env); value != "" {
+			*target = value
+		}
+	}
+
+	if value, ok := lookupEnv("SUBMISSION_QUEUE_PATH"); ok {
+		c.SubmissionQueuePath = &value
+	}
+
+	if value := getenv("SIGNED_UPDATES"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid SIGNED_UPDATES %q: %v", value, err)
+		}
+		c.SignedUpdates = enabled
+	}
+
+	if value := getenv("PIPELINE_DEPTH"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid PIPELINE_DEPTH %q: %v", value, err)
+		}
+		c.PipelineDepth = depth
+	}
+
+	if value := getenv("RPC_VSOCK_PORT"); value != "" {
+		port, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid RPC_VSOCK_PORT %q: %v", value, err)
+		}
+		c.RPCVsockPort = uint32(port)
+	}
+
+	if value := getenv("RELAYER_VSOCK_PORT"); value != "" {
+		port, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid RELAYER_VSOCK_PORT %q: %v", value, err)
+		}
+		relayerPort := uint32(port)
+		c.RelayerVsockPort = &relayerPort
+	}
+
+	for env, target := range map[string]*uint64{
+		"CHAIN_ID":                &c.ChainID,
+		"ATTESTATION_GAS_LIMIT":   &c.AttestationGasLimit,
+		"SIGNED_UPDATE_GAS_LIMIT": &c.SignedUpdateGasLimit,
+		"CONFIRMATION_DEPTH":      &c.ConfirmationDepth,
+	} {
+		if value := getenv(env); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %v", env, value, err)
+			}
+			*target = parsed
+		}
+	}
+	return nil
+}
+
+func envString(env string, target *string) {
+	if value := os.Getenv(env); value != "" {
+		*target = value
+	}
+}
+
+func envUint32(env string, target *uint32) error {
+	if value := os.Getenv(env); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", env, value, err)
+		}
+		*target = uint32(parsed)
+	}
+	return nil
+}
+
+func envUint64(env string, target *uint64) error {
+	if value := os.Getenv(env); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", env, value, err)
+		}
+		*target = parsed
+	}
+	return nil
+}
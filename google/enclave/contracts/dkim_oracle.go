@@ -0,0 +1,653 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// DKIMOraclePtrs is an auto generated low-level Go binding around an user-defined struct.
+type DKIMOraclePtrs struct {
+	ModuleID  *big.Int
+	Timestamp uint64
+	Digest    *big.Int
+	Pcrs      []*big.Int
+	Cert      *big.Int
+	Cabundle  []*big.Int
+	PublicKey *big.Int
+	UserData  *big.Int
+	Nonce     *big.Int
+}
+
+// DKIMOracleMetaData contains all meta data concerning the DKIMOracle contract.
+var DKIMOracleMetaData = &bind.MetaData{
+	ABI: "[{\"type\":\"constructor\",\"inputs\":[{\"name\":\"_certManager\",\"type\":\"address\",\"internalType\":\"contractICertManager\"}],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"ATTESTATION_TBS_PREFIX\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"ATTESTATION_DIGEST\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"CERTIFICATE_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"PUBLIC_KEY_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"MODULE_ID_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"TIMESTAMP_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"USER_DATA_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"CABUNDLE_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"DIGEST_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"NONCE_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"PCRS_KEY\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"certManager\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\",\"internalType\":\"contractICertManager\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"decodeAttestationTbs\",\"inputs\":[{\"name\":\"attestation\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"outputs\":[{\"name\":\"attestationTbs\",\"type\":\"bytes\",\"internalType\":\"bytes\"},{\"name\":\"signature\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"stateMutability\":\"pure\"},{\"type\":\"function\",\"name\":\"validateAttestation\",\"inputs\":[{\"name\":\"attestationTbs\",\"type\":\"bytes\",\"internalType\":\"bytes\"},{\"name\":\"signature\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"outputs\":[{\"name\":\"\",\"type\":\"tuple\",\"internalType\":\"structDKIMOracle.Ptrs\",\"components\":[{\"name\":\"moduleID\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"timestamp\",\"type\":\"uint64\",\"internalType\":\"uint64\"},{\"name\":\"digest\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"pcrs\",\"type\":\"uint256[]\",\"internalType\":\"CborElement[]\"},{\"name\":\"cert\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"cabundle\",\"type\":\"uint256[]\",\"internalType\":\"CborElement[]\"},{\"name\":\"publicKey\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"userData\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"nonce\",\"type\":\"uint256\",\"internalType\":\"CborElement\"}]}],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"decodeAndValidateAttestation\",\"inputs\":[{\"name\":\"attestation\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"outputs\":[{\"name\":\"\",\"type\":\"tuple\",\"internalType\":\"structDKIMOracle.Ptrs\",\"components\":[{\"name\":\"moduleID\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"timestamp\",\"type\":\"uint64\",\"internalType\":\"uint64\"},{\"name\":\"digest\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"pcrs\",\"type\":\"uint256[]\",\"internalType\":\"CborElement[]\"},{\"name\":\"cert\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"cabundle\",\"type\":\"uint256[]\",\"internalType\":\"CborElement[]\"},{\"name\":\"publicKey\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"userData\",\"type\":\"uint256\",\"internalType\":\"CborElement\"},{\"name\":\"nonce\",\"type\":\"uint256\",\"internalType\":\"CborElement\"}]}],\"stateMutability\":\"nonpayable\"}]",
+}
+
+// DKIMOracleABI is the input ABI used to generate the binding from.
+// Deprecated: Use DKIMOracleMetaData.ABI instead.
+var DKIMOracleABI = DKIMOracleMetaData.ABI
+
+// DKIMOracle is an auto generated Go binding around an Ethereum contract.
+type DKIMOracle struct {
+	DKIMOracleCaller     // Read-only binding to the contract
+	DKIMOracleTransactor // Write-only binding to the contract
+	DKIMOracleFilterer   // Log filterer for contract events
+}
+
+// DKIMOracleCaller is an auto generated read-only Go binding around an Ethereum contract.
+type DKIMOracleCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMOracleTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type DKIMOracleTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMOracleFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type DKIMOracleFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMOracleSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type DKIMOracleSession struct {
+	Contract     *DKIMOracle       // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// DKIMOracleCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type DKIMOracleCallerSession struct {
+	Contract *DKIMOracleCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts     // Call options to use throughout this session
+}
+
+// DKIMOracleTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type DKIMOracleTransactorSession struct {
+	Contract     *DKIMOracleTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts     // Transaction auth options to use throughout this session
+}
+
+// DKIMOracleRaw is an auto generated low-level Go binding around an Ethereum contract.
+type DKIMOracleRaw struct {
+	Contract *DKIMOracle // Generic contract binding to access the raw methods on
+}
+
+// DKIMOracleCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type DKIMOracleCallerRaw struct {
+	Contract *DKIMOracleCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// DKIMOracleTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type DKIMOracleTransactorRaw struct {
+	Contract *DKIMOracleTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewDKIMOracle creates a new instance of DKIMOracle, bound to a specific deployed contract.
+func NewDKIMOracle(address common.Address, backend bind.ContractBackend) (*DKIMOracle, error) {
+	contract, err := bindDKIMOracle(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMOracle{DKIMOracleCaller: DKIMOracleCaller{contract: contract}, DKIMOracleTransactor: DKIMOracleTransactor{contract: contract}, DKIMOracleFilterer: DKIMOracleFilterer{contract: contract}}, nil
+}
+
+// NewDKIMOracleCaller creates a new read-only instance of DKIMOracle, bound to a specific deployed contract.
+func NewDKIMOracleCaller(address common.Address, caller bind.ContractCaller) (*DKIMOracleCaller, error) {
+	contract, err := bindDKIMOracle(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMOracleCaller{contract: contract}, nil
+}
+
+// NewDKIMOracleTransactor creates a new write-only instance of DKIMOracle, bound to a specific deployed contract.
+func NewDKIMOracleTransactor(address common.Address, transactor bind.ContractTransactor) (*DKIMOracleTransactor, error) {
+	contract, err := bindDKIMOracle(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMOracleTransactor{contract: contract}, nil
+}
+
+// NewDKIMOracleFilterer creates a new log filterer instance of DKIMOracle, bound to a specific deployed contract.
+func NewDKIMOracleFilterer(address common.Address, filterer bind.ContractFilterer) (*DKIMOracleFilterer, error) {
+	contract, err := bindDKIMOracle(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMOracleFilterer{contract: contract}, nil
+}
+
+// bindDKIMOracle binds a generic wrapper to an already deployed contract.
+func bindDKIMOracle(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := DKIMOracleMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DKIMOracle *DKIMOracleRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DKIMOracle.Contract.DKIMOracleCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DKIMOracle *DKIMOracleRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.DKIMOracleTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DKIMOracle *DKIMOracleRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.DKIMOracleTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DKIMOracle *DKIMOracleCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DKIMOracle.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DKIMOracle *DKIMOracleTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DKIMOracle *DKIMOracleTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.contract.Transact(opts, method, params...)
+}
+
+// ATTESTATIONDIGEST is a free data retrieval call binding the contract method 0x3893af6d.
+//
+// Solidity: function ATTESTATION_DIGEST() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) ATTESTATIONDIGEST(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "ATTESTATION_DIGEST")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// ATTESTATIONDIGEST is a free data retrieval call binding the contract method 0x3893af6d.
+//
+// Solidity: function ATTESTATION_DIGEST() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) ATTESTATIONDIGEST() ([32]byte, error) {
+	return _DKIMOracle.Contract.ATTESTATIONDIGEST(&_DKIMOracle.CallOpts)
+}
+
+// ATTESTATIONDIGEST is a free data retrieval call binding the contract method 0x3893af6d.
+//
+// Solidity: function ATTESTATION_DIGEST() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) ATTESTATIONDIGEST() ([32]byte, error) {
+	return _DKIMOracle.Contract.ATTESTATIONDIGEST(&_DKIMOracle.CallOpts)
+}
+
+// ATTESTATIONTBSPREFIX is a free data retrieval call binding the contract method 0x2d4bad8a.
+//
+// Solidity: function ATTESTATION_TBS_PREFIX() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) ATTESTATIONTBSPREFIX(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "ATTESTATION_TBS_PREFIX")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// ATTESTATIONTBSPREFIX is a free data retrieval call binding the contract method 0x2d4bad8a.
+//
+// Solidity: function ATTESTATION_TBS_PREFIX() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) ATTESTATIONTBSPREFIX() ([32]byte, error) {
+	return _DKIMOracle.Contract.ATTESTATIONTBSPREFIX(&_DKIMOracle.CallOpts)
+}
+
+// ATTESTATIONTBSPREFIX is a free data retrieval call binding the contract method 0x2d4bad8a.
+//
+// Solidity: function ATTESTATION_TBS_PREFIX() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) ATTESTATIONTBSPREFIX() ([32]byte, error) {
+	return _DKIMOracle.Contract.ATTESTATIONTBSPREFIX(&_DKIMOracle.CallOpts)
+}
+
+// CABUNDLEKEY is a free data retrieval call binding the contract method 0x9cc3eb48.
+//
+// Solidity: function CABUNDLE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) CABUNDLEKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "CABUNDLE_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// CABUNDLEKEY is a free data retrieval call binding the contract method 0x9cc3eb48.
+//
+// Solidity: function CABUNDLE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) CABUNDLEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.CABUNDLEKEY(&_DKIMOracle.CallOpts)
+}
+
+// CABUNDLEKEY is a free data retrieval call binding the contract method 0x9cc3eb48.
+//
+// Solidity: function CABUNDLE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) CABUNDLEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.CABUNDLEKEY(&_DKIMOracle.CallOpts)
+}
+
+// CERTIFICATEKEY is a free data retrieval call binding the contract method 0xae951149.
+//
+// Solidity: function CERTIFICATE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) CERTIFICATEKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "CERTIFICATE_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// CERTIFICATEKEY is a free data retrieval call binding the contract method 0xae951149.
+//
+// Solidity: function CERTIFICATE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) CERTIFICATEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.CERTIFICATEKEY(&_DKIMOracle.CallOpts)
+}
+
+// CERTIFICATEKEY is a free data retrieval call binding the contract method 0xae951149.
+//
+// Solidity: function CERTIFICATE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) CERTIFICATEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.CERTIFICATEKEY(&_DKIMOracle.CallOpts)
+}
+
+// DIGESTKEY is a free data retrieval call binding the contract method 0x6be1e68b.
+//
+// Solidity: function DIGEST_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) DIGESTKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "DIGEST_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// DIGESTKEY is a free data retrieval call binding the contract method 0x6be1e68b.
+//
+// Solidity: function DIGEST_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) DIGESTKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.DIGESTKEY(&_DKIMOracle.CallOpts)
+}
+
+// DIGESTKEY is a free data retrieval call binding the contract method 0x6be1e68b.
+//
+// Solidity: function DIGEST_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) DIGESTKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.DIGESTKEY(&_DKIMOracle.CallOpts)
+}
+
+// MODULEIDKEY is a free data retrieval call binding the contract method 0x9adb2d68.
+//
+// Solidity: function MODULE_ID_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) MODULEIDKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "MODULE_ID_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// MODULEIDKEY is a free data retrieval call binding the contract method 0x9adb2d68.
+//
+// Solidity: function MODULE_ID_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) MODULEIDKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.MODULEIDKEY(&_DKIMOracle.CallOpts)
+}
+
+// MODULEIDKEY is a free data retrieval call binding the contract method 0x9adb2d68.
+//
+// Solidity: function MODULE_ID_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) MODULEIDKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.MODULEIDKEY(&_DKIMOracle.CallOpts)
+}
+
+// NONCEKEY is a free data retrieval call binding the contract method 0x6378aad5.
+//
+// Solidity: function NONCE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) NONCEKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "NONCE_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// NONCEKEY is a free data retrieval call binding the contract method 0x6378aad5.
+//
+// Solidity: function NONCE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) NONCEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.NONCEKEY(&_DKIMOracle.CallOpts)
+}
+
+// NONCEKEY is a free data retrieval call binding the contract method 0x6378aad5.
+//
+// Solidity: function NONCE_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) NONCEKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.NONCEKEY(&_DKIMOracle.CallOpts)
+}
+
+// PCRSKEY is a free data retrieval call binding the contract method 0xb22bed7e.
+//
+// Solidity: function PCRS_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) PCRSKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "PCRS_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// PCRSKEY is a free data retrieval call binding the contract method 0xb22bed7e.
+//
+// Solidity: function PCRS_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) PCRSKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.PCRSKEY(&_DKIMOracle.CallOpts)
+}
+
+// PCRSKEY is a free data retrieval call binding the contract method 0xb22bed7e.
+//
+// Solidity: function PCRS_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) PCRSKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.PCRSKEY(&_DKIMOracle.CallOpts)
+}
+
+// PUBLICKEYKEY is a free data retrieval call binding the contract method 0xe8b6d3fe.
+//
+// Solidity: function PUBLIC_KEY_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) PUBLICKEYKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "PUBLIC_KEY_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// PUBLICKEYKEY is a free data retrieval call binding the contract method 0xe8b6d3fe.
+//
+// Solidity: function PUBLIC_KEY_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) PUBLICKEYKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.PUBLICKEYKEY(&_DKIMOracle.CallOpts)
+}
+
+// PUBLICKEYKEY is a free data retrieval call binding the contract method 0xe8b6d3fe.
+//
+// Solidity: function PUBLIC_KEY_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) PUBLICKEYKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.PUBLICKEYKEY(&_DKIMOracle.CallOpts)
+}
+
+// TIMESTAMPKEY is a free data retrieval call binding the contract method 0xe0a655ff.
+//
+// Solidity: function TIMESTAMP_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) TIMESTAMPKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "TIMESTAMP_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// TIMESTAMPKEY is a free data retrieval call binding the contract method 0xe0a655ff.
+//
+// Solidity: function TIMESTAMP_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) TIMESTAMPKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.TIMESTAMPKEY(&_DKIMOracle.CallOpts)
+}
+
+// TIMESTAMPKEY is a free data retrieval call binding the contract method 0xe0a655ff.
+//
+// Solidity: function TIMESTAMP_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) TIMESTAMPKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.TIMESTAMPKEY(&_DKIMOracle.CallOpts)
+}
+
+// USERDATAKEY is a free data retrieval call binding the contract method 0xcebf08d7.
+//
+// Solidity: function USER_DATA_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCaller) USERDATAKEY(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "USER_DATA_KEY")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// USERDATAKEY is a free data retrieval call binding the contract method 0xcebf08d7.
+//
+// Solidity: function USER_DATA_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleSession) USERDATAKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.USERDATAKEY(&_DKIMOracle.CallOpts)
+}
+
+// USERDATAKEY is a free data retrieval call binding the contract method 0xcebf08d7.
+//
+// Solidity: function USER_DATA_KEY() view returns(bytes32)
+func (_DKIMOracle *DKIMOracleCallerSession) USERDATAKEY() ([32]byte, error) {
+	return _DKIMOracle.Contract.USERDATAKEY(&_DKIMOracle.CallOpts)
+}
+
+// CertManager is a free data retrieval call binding the contract method 0x739e8484.
+//
+// Solidity: function certManager() view returns(address)
+func (_DKIMOracle *DKIMOracleCaller) CertManager(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "certManager")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// CertManager is a free data retrieval call binding the contract method 0x739e8484.
+//
+// Solidity: function certManager() view returns(address)
+func (_DKIMOracle *DKIMOracleSession) CertManager() (common.Address, error) {
+	return _DKIMOracle.Contract.CertManager(&_DKIMOracle.CallOpts)
+}
+
+// CertManager is a free data retrieval call binding the contract method 0x739e8484.
+//
+// Solidity: function certManager() view returns(address)
+func (_DKIMOracle *DKIMOracleCallerSession) CertManager() (common.Address, error) {
+	return _DKIMOracle.Contract.CertManager(&_DKIMOracle.CallOpts)
+}
+
+// DecodeAttestationTbs is a free data retrieval call binding the contract method 0xa903a277.
+//
+// Solidity: function decodeAttestationTbs(bytes attestation) pure returns(bytes attestationTbs, bytes signature)
+func (_DKIMOracle *DKIMOracleCaller) DecodeAttestationTbs(opts *bind.CallOpts, attestation []byte) (struct {
+	AttestationTbs []byte
+	Signature      []byte
+}, error) {
+	var out []interface{}
+	err := _DKIMOracle.contract.Call(opts, &out, "decodeAttestationTbs", attestation)
+
+	outstruct := new(struct {
+		AttestationTbs []byte
+		Signature      []byte
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.AttestationTbs = *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+	outstruct.Signature = *abi.ConvertType(out[1], new([]byte)).(*[]byte)
+
+	return *outstruct, err
+
+}
+
+// DecodeAttestationTbs is a free data retrieval call binding the contract method 0xa903a277.
+//
+// Solidity: function decodeAttestationTbs(bytes attestation) pure returns(bytes attestationTbs, bytes signature)
+func (_DKIMOracle *DKIMOracleSession) DecodeAttestationTbs(attestation []byte) (struct {
+	AttestationTbs []byte
+	Signature      []byte
+}, error) {
+	return _DKIMOracle.Contract.DecodeAttestationTbs(&_DKIMOracle.CallOpts, attestation)
+}
+
+// DecodeAttestationTbs is a free data retrieval call binding the contract method 0xa903a277.
+//
+// Solidity: function decodeAttestationTbs(bytes attestation) pure returns(bytes attestationTbs, bytes signature)
+func (_DKIMOracle *DKIMOracleCallerSession) DecodeAttestationTbs(attestation []byte) (struct {
+	AttestationTbs []byte
+	Signature      []byte
+}, error) {
+	return _DKIMOracle.Contract.DecodeAttestationTbs(&_DKIMOracle.CallOpts, attestation)
+}
+
+// DecodeAndValidateAttestation is a paid mutator transaction binding the contract method 0x693579b1.
+//
+// Solidity: function decodeAndValidateAttestation(bytes attestation) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleTransactor) DecodeAndValidateAttestation(opts *bind.TransactOpts, attestation []byte) (*types.Transaction, error) {
+	return _DKIMOracle.contract.Transact(opts, "decodeAndValidateAttestation", attestation)
+}
+
+// DecodeAndValidateAttestation is a paid mutator transaction binding the contract method 0x693579b1.
+//
+// Solidity: function decodeAndValidateAttestation(bytes attestation) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleSession) DecodeAndValidateAttestation(attestation []byte) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.DecodeAndValidateAttestation(&_DKIMOracle.TransactOpts, attestation)
+}
+
+// DecodeAndValidateAttestation is a paid mutator transaction binding the contract method 0x693579b1.
+//
+// Solidity: function decodeAndValidateAttestation(bytes attestation) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleTransactorSession) DecodeAndValidateAttestation(attestation []byte) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.DecodeAndValidateAttestation(&_DKIMOracle.TransactOpts, attestation)
+}
+
+// ValidateAttestation is a paid mutator transaction binding the contract method 0x05f7aead.
+//
+// Solidity: function validateAttestation(bytes attestationTbs, bytes signature) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleTransactor) ValidateAttestation(opts *bind.TransactOpts, attestationTbs []byte, signature []byte) (*types.Transaction, error) {
+	return _DKIMOracle.contract.Transact(opts, "validateAttestation", attestationTbs, signature)
+}
+
+// ValidateAttestation is a paid mutator transaction binding the contract method 0x05f7aead.
+//
+// Solidity: function validateAttestation(bytes attestationTbs, bytes signature) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleSession) ValidateAttestation(attestationTbs []byte, signature []byte) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.ValidateAttestation(&_DKIMOracle.TransactOpts, attestationTbs, signature)
+}
+
+// ValidateAttestation is a paid mutator transaction binding the contract method 0x05f7aead.
+//
+// Solidity: function validateAttestation(bytes attestationTbs, bytes signature) returns((uint256,uint64,uint256,uint256[],uint256,uint256[],uint256,uint256,uint256))
+func (_DKIMOracle *DKIMOracleTransactorSession) ValidateAttestation(attestationTbs []byte, signature []byte) (*types.Transaction, error) {
+	return _DKIMOracle.Contract.ValidateAttestation(&_DKIMOracle.TransactOpts, attestationTbs, signature)
+}
@@ -0,0 +1,635 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// DKIMRegistryMetaData contains all meta data concerning the DKIMRegistry contract.
+var DKIMRegistryMetaData = &bind.MetaData{
+	ABI: "[{\"type\":\"constructor\",\"inputs\":[{\"name\":\"_dkimOracle\",\"type\":\"address\",\"internalType\":\"contractDKIMOracle\"}],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"dkimOracle\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\",\"internalType\":\"contractDKIMOracle\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"storeDKIMKeysFromAttestation\",\"inputs\":[{\"name\":\"attestation\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"getDKIMKey\",\"inputs\":[{\"name\":\"domain\",\"type\":\"string\",\"internalType\":\"string\"},{\"name\":\"selector\",\"type\":\"string\",\"internalType\":\"string\"}],\"outputs\":[{\"name\":\"publicKey\",\"type\":\"bytes\",\"internalType\":\"bytes\"},{\"name\":\"isValid\",\"type\":\"bool\",\"internalType\":\"bool\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"getAllDKIMKeys\",\"inputs\":[],\"outputs\":[{\"name\":\"domains\",\"type\":\"string[]\",\"internalType\":\"string[]\"},{\"name\":\"selectors\",\"type\":\"string[]\",\"internalType\":\"string[]\"},{\"name\":\"publicKeys\",\"type\":\"bytes[]\",\"internalType\":\"bytes[]\"}],\"stateMutability\":\"view\"},{\"type\":\"event\",\"name\":\"KeyRegistered\",\"anonymous\":false,\"inputs\":[{\"name\":\"publicKey\",\"type\":\"bytes\",\"internalType\":\"bytes\",\"indexed\":false},{\"name\":\"domain\",\"type\":\"string\",\"internalType\":\"string\",\"indexed\":true},{\"name\":\"selector\",\"type\":\"string\",\"internalType\":\"string\",\"indexed\":true}]},{\"type\":\"event\",\"name\":\"DKIMKeyRevoked\",\"anonymous\":false,\"inputs\":[{\"name\":\"domain\",\"type\":\"string\",\"internalType\":\"string\",\"indexed\":true},{\"name\":\"selector\",\"type\":\"string\",\"internalType\":\"string\",\"indexed\":true}]}]",
+}
+
+// DKIMRegistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use DKIMRegistryMetaData.ABI instead.
+var DKIMRegistryABI = DKIMRegistryMetaData.ABI
+
+// DKIMRegistry is an auto generated Go binding around an Ethereum contract.
+type DKIMRegistry struct {
+	DKIMRegistryCaller     // Read-only binding to the contract
+	DKIMRegistryTransactor // Write-only binding to the contract
+	DKIMRegistryFilterer   // Log filterer for contract events
+}
+
+// DKIMRegistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type DKIMRegistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMRegistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type DKIMRegistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMRegistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type DKIMRegistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// DKIMRegistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type DKIMRegistrySession struct {
+	Contract     *DKIMRegistry     // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// DKIMRegistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type DKIMRegistryCallerSession struct {
+	Contract *DKIMRegistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts       // Call options to use throughout this session
+}
+
+// DKIMRegistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type DKIMRegistryTransactorSession struct {
+	Contract     *DKIMRegistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts       // Transaction auth options to use throughout this session
+}
+
+// DKIMRegistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type DKIMRegistryRaw struct {
+	Contract *DKIMRegistry // Generic contract binding to access the raw methods on
+}
+
+// DKIMRegistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type DKIMRegistryCallerRaw struct {
+	Contract *DKIMRegistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// DKIMRegistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type DKIMRegistryTransactorRaw struct {
+	Contract *DKIMRegistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewDKIMRegistry creates a new instance of DKIMRegistry, bound to a specific deployed contract.
+func NewDKIMRegistry(address common.Address, backend bind.ContractBackend) (*DKIMRegistry, error) {
+	contract, err := bindDKIMRegistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistry{DKIMRegistryCaller: DKIMRegistryCaller{contract: contract}, DKIMRegistryTransactor: DKIMRegistryTransactor{contract: contract}, DKIMRegistryFilterer: DKIMRegistryFilterer{contract: contract}}, nil
+}
+
+// NewDKIMRegistryCaller creates a new read-only instance of DKIMRegistry, bound to a specific deployed contract.
+func NewDKIMRegistryCaller(address common.Address, caller bind.ContractCaller) (*DKIMRegistryCaller, error) {
+	contract, err := bindDKIMRegistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistryCaller{contract: contract}, nil
+}
+
+// NewDKIMRegistryTransactor creates a new write-only instance of DKIMRegistry, bound to a specific deployed contract.
+func NewDKIMRegistryTransactor(address common.Address, transactor bind.ContractTransactor) (*DKIMRegistryTransactor, error) {
+	contract, err := bindDKIMRegistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistryTransactor{contract: contract}, nil
+}
+
+// NewDKIMRegistryFilterer creates a new log filterer instance of DKIMRegistry, bound to a specific deployed contract.
+func NewDKIMRegistryFilterer(address common.Address, filterer bind.ContractFilterer) (*DKIMRegistryFilterer, error) {
+	contract, err := bindDKIMRegistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistryFilterer{contract: contract}, nil
+}
+
+// bindDKIMRegistry binds a generic wrapper to an already deployed contract.
+func bindDKIMRegistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := DKIMRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DKIMRegistry *DKIMRegistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DKIMRegistry.Contract.DKIMRegistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DKIMRegistry *DKIMRegistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.DKIMRegistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DKIMRegistry *DKIMRegistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.DKIMRegistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_DKIMRegistry *DKIMRegistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _DKIMRegistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_DKIMRegistry *DKIMRegistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_DKIMRegistry *DKIMRegistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// DkimOracle is a free data retrieval call binding the contract method 0x3f1132ff.
+//
+// Solidity: function dkimOracle() view returns(address)
+func (_DKIMRegistry *DKIMRegistryCaller) DkimOracle(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _DKIMRegistry.contract.Call(opts, &out, "dkimOracle")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// DkimOracle is a free data retrieval call binding the contract method 0x3f1132ff.
+//
+// Solidity: function dkimOracle() view returns(address)
+func (_DKIMRegistry *DKIMRegistrySession) DkimOracle() (common.Address, error) {
+	return _DKIMRegistry.Contract.DkimOracle(&_DKIMRegistry.CallOpts)
+}
+
+// DkimOracle is a free data retrieval call binding the contract method 0x3f1132ff.
+//
+// Solidity: function dkimOracle() view returns(address)
+func (_DKIMRegistry *DKIMRegistryCallerSession) DkimOracle() (common.Address, error) {
+	return _DKIMRegistry.Contract.DkimOracle(&_DKIMRegistry.CallOpts)
+}
+
+// GetAllDKIMKeys is a free data retrieval call binding the contract method 0x7ddb874f.
+//
+// Solidity: function getAllDKIMKeys() view returns(string[] domains, string[] selectors, bytes[] publicKeys)
+func (_DKIMRegistry *DKIMRegistryCaller) GetAllDKIMKeys(opts *bind.CallOpts) (struct {
+	Domains    []string
+	Selectors  []string
+	PublicKeys [][]byte
+}, error) {
+	var out []interface{}
+	err := _DKIMRegistry.contract.Call(opts, &out, "getAllDKIMKeys")
+
+	outstruct := new(struct {
+		Domains    []string
+		Selectors  []string
+		PublicKeys [][]byte
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Domains = *abi.ConvertType(out[0], new([]string)).(*[]string)
+	outstruct.Selectors = *abi.ConvertType(out[1], new([]string)).(*[]string)
+	outstruct.PublicKeys = *abi.ConvertType(out[2], new([][]byte)).(*[][]byte)
+
+	return *outstruct, err
+
+}
+
+// GetAllDKIMKeys is a free data retrieval call binding the contract method 0x7ddb874f.
+//
+// Solidity: function getAllDKIMKeys() view returns(string[] domains, string[] selectors, bytes[] publicKeys)
+func (_DKIMRegistry *DKIMRegistrySession) GetAllDKIMKeys() (struct {
+	Domains    []string
+	Selectors  []string
+	PublicKeys [][]byte
+}, error) {
+	return _DKIMRegistry.Contract.GetAllDKIMKeys(&_DKIMRegistry.CallOpts)
+}
+
+// GetAllDKIMKeys is a free data retrieval call binding the contract method 0x7ddb874f.
+//
+// Solidity: function getAllDKIMKeys() view returns(string[] domains, string[] selectors, bytes[] publicKeys)
+func (_DKIMRegistry *DKIMRegistryCallerSession) GetAllDKIMKeys() (struct {
+	Domains    []string
+	Selectors  []string
+	PublicKeys [][]byte
+}, error) {
+	return _DKIMRegistry.Contract.GetAllDKIMKeys(&_DKIMRegistry.CallOpts)
+}
+
+// GetDKIMKey is a free data retrieval call binding the contract method 0x6bd462da.
+//
+// Solidity: function getDKIMKey(string domain, string selector) view returns(bytes publicKey, bool isValid)
+func (_DKIMRegistry *DKIMRegistryCaller) GetDKIMKey(opts *bind.CallOpts, domain string, selector string) (struct {
+	PublicKey []byte
+	IsValid   bool
+}, error) {
+	var out []interface{}
+	err := _DKIMRegistry.contract.Call(opts, &out, "getDKIMKey", domain, selector)
+
+	outstruct := new(struct {
+		PublicKey []byte
+		IsValid   bool
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.PublicKey = *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+	outstruct.IsValid = *abi.ConvertType(out[1], new(bool)).(*bool)
+
+	return *outstruct, err
+
+}
+
+// GetDKIMKey is a free data retrieval call binding the contract method 0x6bd462da.
+//
+// Solidity: function getDKIMKey(string domain, string selector) view returns(bytes publicKey, bool isValid)
+func (_DKIMRegistry *DKIMRegistrySession) GetDKIMKey(domain string, selector string) (struct {
+	PublicKey []byte
+	IsValid   bool
+}, error) {
+	return _DKIMRegistry.Contract.GetDKIMKey(&_DKIMRegistry.CallOpts, domain, selector)
+}
+
+// GetDKIMKey is a free data retrieval call binding the contract method 0x6bd462da.
+//
+// Solidity: function getDKIMKey(string domain, string selector) view returns(bytes publicKey, bool isValid)
+func (_DKIMRegistry *DKIMRegistryCallerSession) GetDKIMKey(domain string, selector string) (struct {
+	PublicKey []byte
+	IsValid   bool
+}, error) {
+	return _DKIMRegistry.Contract.GetDKIMKey(&_DKIMRegistry.CallOpts, domain, selector)
+}
+
+// StoreDKIMKeysFromAttestation is a paid mutator transaction binding the contract method 0x029956d8.
+//
+// Solidity: function storeDKIMKeysFromAttestation(bytes attestation) returns()
+func (_DKIMRegistry *DKIMRegistryTransactor) StoreDKIMKeysFromAttestation(opts *bind.TransactOpts, attestation []byte) (*types.Transaction, error) {
+	return _DKIMRegistry.contract.Transact(opts, "storeDKIMKeysFromAttestation", attestation)
+}
+
+// StoreDKIMKeysFromAttestation is a paid mutator transaction binding the contract method 0x029956d8.
+//
+// Solidity: function storeDKIMKeysFromAttestation(bytes attestation) returns()
+func (_DKIMRegistry *DKIMRegistrySession) StoreDKIMKeysFromAttestation(attestation []byte) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.StoreDKIMKeysFromAttestation(&_DKIMRegistry.TransactOpts, attestation)
+}
+
+// StoreDKIMKeysFromAttestation is a paid mutator transaction binding the contract method 0x029956d8.
+//
+// Solidity: function storeDKIMKeysFromAttestation(bytes attestation) returns()
+func (_DKIMRegistry *DKIMRegistryTransactorSession) StoreDKIMKeysFromAttestation(attestation []byte) (*types.Transaction, error) {
+	return _DKIMRegistry.Contract.StoreDKIMKeysFromAttestation(&_DKIMRegistry.TransactOpts, attestation)
+}
+
+// DKIMRegistryDKIMKeyRevokedIterator is returned from FilterDKIMKeyRevoked and is used to iterate over the raw logs and unpacked data for DKIMKeyRevoked events raised by the DKIMRegistry contract.
+type DKIMRegistryDKIMKeyRevokedIterator struct {
+	Event *DKIMRegistryDKIMKeyRevoked // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DKIMRegistryDKIMKeyRevokedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DKIMRegistryDKIMKeyRevoked)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DKIMRegistryDKIMKeyRevoked)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DKIMRegistryDKIMKeyRevokedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DKIMRegistryDKIMKeyRevokedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DKIMRegistryDKIMKeyRevoked represents a DKIMKeyRevoked event raised by the DKIMRegistry contract.
+type DKIMRegistryDKIMKeyRevoked struct {
+	Domain   common.Hash
+	Selector common.Hash
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterDKIMKeyRevoked is a free log retrieval operation binding the contract event 0x56112bc4278cc917fff21911686a1d823bad151210cccbe58c5db3ebc4382828.
+//
+// Solidity: event DKIMKeyRevoked(string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) FilterDKIMKeyRevoked(opts *bind.FilterOpts, domain []string, selector []string) (*DKIMRegistryDKIMKeyRevokedIterator, error) {
+
+	var domainRule []interface{}
+	for _, domainItem := range domain {
+		domainRule = append(domainRule, domainItem)
+	}
+	var selectorRule []interface{}
+	for _, selectorItem := range selector {
+		selectorRule = append(selectorRule, selectorItem)
+	}
+
+	logs, sub, err := _DKIMRegistry.contract.FilterLogs(opts, "DKIMKeyRevoked", domainRule, selectorRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistryDKIMKeyRevokedIterator{contract: _DKIMRegistry.contract, event: "DKIMKeyRevoked", logs: logs, sub: sub}, nil
+}
+
+// WatchDKIMKeyRevoked is a free log subscription operation binding the contract event 0x56112bc4278cc917fff21911686a1d823bad151210cccbe58c5db3ebc4382828.
+//
+// Solidity: event DKIMKeyRevoked(string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) WatchDKIMKeyRevoked(opts *bind.WatchOpts, sink chan<- *DKIMRegistryDKIMKeyRevoked, domain []string, selector []string) (event.Subscription, error) {
+
+	var domainRule []interface{}
+	for _, domainItem := range domain {
+		domainRule = append(domainRule, domainItem)
+	}
+	var selectorRule []interface{}
+	for _, selectorItem := range selector {
+		selectorRule = append(selectorRule, selectorItem)
+	}
+
+	logs, sub, err := _DKIMRegistry.contract.WatchLogs(opts, "DKIMKeyRevoked", domainRule, selectorRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DKIMRegistryDKIMKeyRevoked)
+				if err := _DKIMRegistry.contract.UnpackLog(event, "DKIMKeyRevoked", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDKIMKeyRevoked is a log parse operation binding the contract event 0x56112bc4278cc917fff21911686a1d823bad151210cccbe58c5db3ebc4382828.
+//
+// Solidity: event DKIMKeyRevoked(string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) ParseDKIMKeyRevoked(log types.Log) (*DKIMRegistryDKIMKeyRevoked, error) {
+	event := new(DKIMRegistryDKIMKeyRevoked)
+	if err := _DKIMRegistry.contract.UnpackLog(event, "DKIMKeyRevoked", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// DKIMRegistryKeyRegisteredIterator is returned from FilterKeyRegistered and is used to iterate over the raw logs and unpacked data for KeyRegistered events raised by the DKIMRegistry contract.
+type DKIMRegistryKeyRegisteredIterator struct {
+	Event *DKIMRegistryKeyRegistered // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *DKIMRegistryKeyRegisteredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(DKIMRegistryKeyRegistered)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(DKIMRegistryKeyRegistered)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *DKIMRegistryKeyRegisteredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *DKIMRegistryKeyRegisteredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// DKIMRegistryKeyRegistered represents a KeyRegistered event raised by the DKIMRegistry contract.
+type DKIMRegistryKeyRegistered struct {
+	PublicKey []byte
+	Domain    common.Hash
+	Selector  common.Hash
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterKeyRegistered is a free log retrieval operation binding the contract event 0xa7f1033c8ef70001f4826f37e094fcb26d7ff2244ffb772e5ccfe4ec26d9e7fb.
+//
+// Solidity: event KeyRegistered(bytes publicKey, string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) FilterKeyRegistered(opts *bind.FilterOpts, domain []string, selector []string) (*DKIMRegistryKeyRegisteredIterator, error) {
+
+	var domainRule []interface{}
+	for _, domainItem := range domain {
+		domainRule = append(domainRule, domainItem)
+	}
+	var selectorRule []interface{}
+	for _, selectorItem := range selector {
+		selectorRule = append(selectorRule, selectorItem)
+	}
+
+	logs, sub, err := _DKIMRegistry.contract.FilterLogs(opts, "KeyRegistered", domainRule, selectorRule)
+	if err != nil {
+		return nil, err
+	}
+	return &DKIMRegistryKeyRegisteredIterator{contract: _DKIMRegistry.contract, event: "KeyRegistered", logs: logs, sub: sub}, nil
+}
+
+// WatchKeyRegistered is a free log subscription operation binding the contract event 0xa7f1033c8ef70001f4826f37e094fcb26d7ff2244ffb772e5ccfe4ec26d9e7fb.
+//
+// Solidity: event KeyRegistered(bytes publicKey, string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) WatchKeyRegistered(opts *bind.WatchOpts, sink chan<- *DKIMRegistryKeyRegistered, domain []string, selector []string) (event.Subscription, error) {
+
+	var domainRule []interface{}
+	for _, domainItem := range domain {
+		domainRule = append(domainRule, domainItem)
+	}
+	var selectorRule []interface{}
+	for _, selectorItem := range selector {
+		selectorRule = append(selectorRule, selectorItem)
+	}
+
+	logs, sub, err := _DKIMRegistry.contract.WatchLogs(opts, "KeyRegistered", domainRule, selectorRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(DKIMRegistryKeyRegistered)
+				if err := _DKIMRegistry.contract.UnpackLog(event, "KeyRegistered", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseKeyRegistered is a log parse operation binding the contract event 0xa7f1033c8ef70001f4826f37e094fcb26d7ff2244ffb772e5ccfe4ec26d9e7fb.
+//
+// Solidity: event KeyRegistered(bytes publicKey, string indexed domain, string indexed selector)
+func (_DKIMRegistry *DKIMRegistryFilterer) ParseKeyRegistered(log types.Log) (*DKIMRegistryKeyRegistered, error) {
+	event := new(DKIMRegistryKeyRegistered)
+	if err := _DKIMRegistry.contract.UnpackLog(event, "KeyRegistered", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
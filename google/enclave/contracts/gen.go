@@ -0,0 +1,6 @@
+package contracts
+
+// The ABIs in abi/ mirror the contracts in dkim-oracle/contracts, regenerate the bindings after changing either.
+//go:generate abigen --abi abi/DKIMOracle.json --pkg contracts --type DKIMOracle --out dkim_oracle.go
+//go:generate abigen --abi abi/DKIMRegistry.json --pkg contracts --type DKIMRegistry --out dkim_registry.go
+//go:generate abigen --abi abi/CertManager.json --pkg contracts --type CertManager --out cert_manager.go
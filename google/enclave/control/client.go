@@ -0,0 +1,88 @@
+package control
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client calls the control API of an enclave, for use on the host.
+type Client struct {
+	rpc *rpc.Client
+}
+
+// Dial connects to the control API the enclave enclaveCID serves on vsockPort. Every call opens its own vsock
+// connection, so the client survives enclave restarts.
+func Dial(ctx context.Context, enclaveCID uint32, vsockPort uint32) (*Client, error) {
+	httpClient := &http.Client{
+		Transport: &network.VsockHTTPRoundTripper{
+			CID:  enclaveCID,
+			Port: vsockPort,
+		},
+	}
+	rpcClient, err := rpc.DialOptions(ctx, "http://enclave", rpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(rpcClient), nil
+}
+
+// NewClient wraps an RPC client that is already connected to the control API.
+func NewClient(rpcClient *rpc.Client) *Client {
+	return &Client{rpc: rpcClient}
+}
+
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+func (c *Client) TriggerRefresh(ctx context.Context) error {
+	var triggered bool
+	return c.rpc.CallContext(ctx, &triggered, Namespace+"_triggerRefresh")
+}
+
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.rpc.CallContext(ctx, &status, Namespace+"_status"); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (c *Client) LatestAttestation(ctx context.Context) (*Attestation, error) {
+	var attestation Attestation
+	if err := c.rpc.CallContext(ctx, &attestation, Namespace+"_latestAttestation"); err != nil {
+		return nil, err
+	}
+	return &attestation, nil
+}
+
+func (c *Client) LastSubmission(ctx context.Context) (*SubmissionReport, error) {
+	var submission SubmissionReport
+	if err := c.rpc.CallContext(ctx, &submission, Namespace+"_lastSubmission"); err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// Attest asks the enclave for a fresh attestation over nonce, at most MaxCallerNonceSize bytes.
+func (c *Client) Attest(ctx context.Context, nonce []byte) (*Attestation, error) {
+	var attestation Attestation
+	if err := c.rpc.CallContext(ctx, &attestation, Namespace+"_attest", hexutil.Bytes(nonce)); err != nil {
+		return nil, err
+	}
+	return &attestation, nil
+}
+
+// AuditRecords reads the audit records of session from sequence from on. The batch names the current session, which
+// starts over from 0 if session has ended.
+func (c *Client) AuditRecords(ctx context.Context, session string, from uint64) (*AuditBatch, error) {
+	var batch AuditBatch
+	if err := c.rpc.CallContext(ctx, &batch, Namespace+"_auditRecords", session, hexutil.Uint64(from)); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
@@ -0,0 +1,148 @@
+package control
+
+import (
+	"sync"
+	"time"
+
+	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/daemon"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Namespace of the control API methods, e.g. control_status.
+const Namespace = "control"
+
+// MaxNonceSize is the largest nonce a Nitro attestation document can carry.
+const MaxNonceSize = 512
+
+// MaxCallerNonceSize is the largest nonce control_attest accepts, which leaves room for the caller nonce wrapping.
+const MaxCallerNonceSize = MaxNonceSize - schema.CallerNonceOverhead
+
+// MaxAuditRecords is the most audit records returned by one call, the caller asks again for the rest.
+const MaxAuditRecords = 100
+
+// Status is a snapshot of the refresh daemon and the chains it publishes to.
+type Status struct {
+	StartedAt   time.Time      `json:"started_at"`
+	ConfigHash  hexutil.Bytes  `json:"config_hash"`
+	SigningKey  common.Address `json:"signing_key"`
+	KeyBound    bool           `json:"key_bound"` // an attestation for SigningKey was accepted on some chain
+	Chains      []ChainStatus  `json:"chains"`
+	Cycles      []daemon.Cycle `json:"cycles"` // oldest first
+	LastSuccess *daemon.Cycle  `json:"last_success,omitempty"`
+}
+
+type ChainStatus struct {
+	Name     string         `json:"name"`
+	ChainID  uint64         `json:"chain_id"`
+	BoundKey common.Address `json:"bound_key"`       // zero until the chain accepted an attestation
+	Error    string         `json:"error,omitempty"` // why the chain was skipped at startup
+}
+
+// Attestation is an attestation document with the payload it attests to.
+type Attestation struct {
+	Document  hexutil.Bytes              `json:"document"`
+	UserData  hexutil.Bytes              `json:"user_data"` // the flattened DKIM keys in the document
+	Nonce     hexutil.Bytes              `json:"nonce,omitempty"` // the nonce field of the document
+	Payload   *attest.AttestationPayload `json:"payload"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// SubmissionReport is the outcome of the last submission to the chains.
+type SubmissionReport struct {
+	Kind       string            `json:"kind"` // attestation or signed_update
+	FinishedAt time.Time         `json:"finished_at"`
+	Error      string            `json:"error,omitempty"`
+	Results    []ChainSubmission `json:"results"`
+}
+
+type ChainSubmission struct {
+	Chain   string                    `json:"chain"`
+	ChainID uint64                    `json:"chain_id"`
+	Outcome *client.SubmissionOutcome `json:"outcome,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// AuditBatch is a run of consecutive audit records of Session.
+type AuditBatch struct {
+	Session string          `json:"session"`
+	Records []*audit.Record `json:"records"`
+}
+
+// State holds what the refresh cycles produced for the control API. The zero value is ready to use.
+type State struct {
+	mu          sync.Mutex
+	payload     *attest.AttestationPayload
+	attestation *Attestation
+	submission  *SubmissionReport
+}
+
+// SetPayload records the most recently fetched keys, whether or not they were submitted.
+func (s *State) SetPayload(payload *attest.AttestationPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payload = payload
+}
+
+func (s *State) Payload() *attest.AttestationPayload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.payload
+}
+
+// SetAttestation records the attestation generated for a submission.
+func (s *State) SetAttestation(document []byte, nonce []byte, payload *attest.AttestationPayload) error {
+	userData, err := attest.DKIMUserData(payload)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attestation = &Attestation{
+		Document:  document,
+		UserData:  userData,
+		Nonce:     nonce,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (s *State) Attestation() *Attestation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attestation
+}
+
+// SetSubmission records the result of submitting to the chains. result may be nil if err stopped the submission
+// before any chain was tried.
+func (s *State) SetSubmission(kind string, result *client.FanOutResult, err error) {
+	report := &SubmissionReport{Kind: kind, FinishedAt: time.Now()}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if result != nil {
+		for _, chain := range result.Results {
+			submission := ChainSubmission{Chain: chain.Chain, ChainID: chain.ChainID, Outcome: chain.Outcome}
+			if chain.Err != nil {
+				submission.Error = chain.Err.Error()
+			}
+			report.Results = append(report.Results, submission)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.submission = report
+}
+
+func (s *State) Submission() *SubmissionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.submission
+}
@@ -0,0 +1,140 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/daemon"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlAPI(t *testing.T) {
+	ctx := context.Background()
+
+	signingKey, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %v", err)
+	}
+	refresher := daemon.New(daemon.Options{}, nil)
+	state := &State{}
+	auditLog, err := audit.NewLog(10)
+	if err != nil {
+		t.Fatalf("Failed to start audit log: %v", err)
+	}
+
+	handler, err := NewHandler(NewService(state, refresher, signingKey, auditLog, []byte{1, 2, 3}), 4096)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to dial control API: %v", err)
+	}
+	c := NewClient(rpcClient)
+	defer c.Close()
+
+	status, err := c.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, signingKey.Address(), status.SigningKey)
+	assert.Equal(t, []byte{1, 2, 3}, []byte(status.ConfigHash))
+	assert.False(t, status.KeyBound)
+	assert.Nil(t, status.LastSuccess)
+
+	assert.NoError(t, c.TriggerRefresh(ctx))
+
+	_, err = c.LatestAttestation(ctx)
+	assert.ErrorContains(t, err, ErrNoAttestation.Error())
+	_, err = c.LastSubmission(ctx)
+	assert.ErrorContains(t, err, ErrNoSubmission.Error())
+	_, err = c.Attest(ctx, []byte("nonce"))
+	assert.ErrorContains(t, err, ErrNoPayload.Error(), "There is nothing to attest before the first fetch")
+
+	payload := &attest.AttestationPayload{
+		Provider: "google",
+		DKIMKeys: map[string]map[string]string{"gmail.com": {"20230601": "AQID"}},
+	}
+	state.SetPayload(payload)
+
+	attestation, err := c.Attest(ctx, []byte("nonce"))
+	assert.NoError(t, err)
+	callerNonce, err := schema.EncodeCallerNonce([]byte("nonce"))
+	assert.NoError(t, err)
+	assert.Equal(t, callerNonce, []byte(attestation.Nonce))
+	assert.NotEmpty(t, attestation.Document)
+	assert.Equal(t, payload.DKIMKeys, attestation.Payload.DKIMKeys)
+
+	_, err = c.Attest(ctx, make([]byte, MaxCallerNonceSize))
+	assert.NoError(t, err, "The largest caller nonce must still fit the document")
+	_, err = c.Attest(ctx, make([]byte, MaxCallerNonceSize+1))
+	assert.Error(t, err, "Nonces a Nitro attestation cannot carry must be refused")
+	_, err = c.Attest(ctx, nil)
+	assert.Error(t, err, "An empty nonce must be refused")
+
+	assert.NoError(t, state.SetAttestation([]byte("document"), []byte("anchor"), payload))
+	latest, err := c.LatestAttestation(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("document"), []byte(latest.Document))
+	assert.NotEmpty(t, latest.UserData)
+
+	state.SetSubmission(client.KindAttestation, &client.FanOutResult{Results: []*client.ChainResult{
+		{Chain: "local", ChainID: 31337},
+		{Chain: "testnet", ChainID: 11155111, Err: errors.New("rpc unavailable")},
+	}}, errors.New("submission failed on 1 of 2 chains"))
+	submission, err := c.LastSubmission(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, client.KindAttestation, submission.Kind)
+	assert.Len(t, submission.Results, 2)
+	assert.Empty(t, submission.Results[0].Error)
+	assert.Equal(t, "rpc unavailable", submission.Results[1].Error)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, auditLog.Append(&audit.Record{Error: "cycle failed"}))
+	}
+	batch, err := c.AuditRecords(ctx, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, auditLog.Session(), batch.Session)
+	assert.Len(t, batch.Records, 3, "An unknown session must be read from its start")
+	batch, err = c.AuditRecords(ctx, batch.Session, 2)
+	assert.NoError(t, err)
+	assert.Len(t, batch.Records, 1)
+	_, err = audit.Verify(batch.Records)
+	assert.ErrorContains(t, err, "earlier records are missing")
+}
+
+func TestControlAPIRequestSizeLimit(t *testing.T) {
+	signingKey, err := identity.NewKey(identity.Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %v", err)
+	}
+	auditLog, err := audit.NewLog(10)
+	if err != nil {
+		t.Fatalf("Failed to start audit log: %v", err)
+	}
+	handler, err := NewHandler(NewService(&State{}, daemon.New(daemon.Options{}, nil), signingKey, auditLog, nil), 256)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to dial control API: %v", err)
+	}
+	c := NewClient(rpcClient)
+	defer c.Close()
+
+	_, err = c.Attest(context.Background(), make([]byte, 256))
+	assert.ErrorContains(t, err, "413", "Requests above the size limit must be refused")
+}
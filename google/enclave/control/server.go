@@ -0,0 +1,211 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/attest"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/daemon"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/identity"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/EkamSinghPandher/Tee-Google/vsock"
+	"github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MetricsPath is where the control server exposes the enclave metrics, for the host to relay.
+const MetricsPath = "/metrics"
+
+// ShutdownTimeout is how long requests in flight when the server is stopped may take to answer.
+var ShutdownTimeout = 10 * time.Second
+
+var (
+	ErrNoPayload     = errors.New("no keys fetched yet")
+	ErrNoAttestation = errors.New("no attestation generated yet")
+	ErrNoSubmission  = errors.New("nothing submitted yet")
+)
+
+// Service implements the control API methods. Every exported method is served as control_<method>.
+type Service struct {
+	state      *State
+	refresher  *daemon.Daemon
+	signingKey *identity.Key
+	auditLog   *audit.Log
+	configHash []byte
+	startedAt  time.Time
+}
+
+func NewService(state *State, refresher *daemon.Daemon, signingKey *identity.Key, auditLog *audit.Log, configHash []byte) *Service {
+	return &Service{
+		state:      state,
+		refresher:  refresher,
+		signingKey: signingKey,
+		auditLog:   auditLog,
+		configHash: configHash,
+		startedAt:  time.Now(),
+	}
+}
+
+// TriggerRefresh starts a refresh cycle without waiting for the schedule.
+func (s *Service) TriggerRefresh() bool {
+	log.Infof("Refresh triggered over the control API")
+	s.refresher.Trigger()
+	return true
+}
+
+func (s *Service) Status() *Status {
+	status := &Status{
+		StartedAt:  s.startedAt,
+		ConfigHash: s.configHash,
+		SigningKey: s.signingKey.Address(),
+		KeyBound:   s.signingKey.IsBound(),
+		Chains:     []ChainStatus{},
+		Cycles:     s.refresher.History(),
+	}
+	if cycle, ok := s.refresher.LastSuccess(); ok {
+		status.LastSuccess = &cycle
+	}
+	for _, target := range client.ChainTargets() {
+		status.Chains = append(status.Chains, ChainStatus{
+			Name:     target.Name(),
+			ChainID:  target.ChainID.Uint64(),
+			BoundKey: target.BoundKey(),
+		})
+	}
+	for _, target := range client.SkippedTargets() {
+		status.Chains = append(status.Chains, ChainStatus{
+			Name:    target.Profile.Name,
+			ChainID: target.Profile.ChainID,
+			Error:   target.Err.Error(),
+		})
+	}
+	return status
+}
+
+// LatestAttestation returns the attestation generated for the last attestation submission.
+func (s *Service) LatestAttestation() (*Attestation, error) {
+	attestation := s.state.Attestation()
+	if attestation == nil {
+		return nil, ErrNoAttestation
+	}
+	return attestation, nil
+}
+
+// LastSubmission returns the outcome of the last attestation or signed update submission.
+func (s *Service) LastSubmission() (*SubmissionReport, error) {
+	submission := s.state.Submission()
+	if submission == nil {
+		return nil, ErrNoSubmission
+	}
+	return submission, nil
+}
+
+// AuditRecords returns up to MaxAuditRecords audit records of session from sequence from on. If session is not the
+// current one, the current session is returned from its start.
+func (s *Service) AuditRecords(session string, from hexutil.Uint64) *AuditBatch {
+	records := s.auditLog.Since(session, uint64(from))
+	if len(records) > MaxAuditRecords {
+		records = records[:MaxAuditRecords]
+	}
+	if records == nil {
+		records = []*audit.Record{}
+	}
+	return &AuditBatch{Session: s.auditLog.Session(), Records: records}
+}
+
+// Attest generates a fresh attestation of the most recently fetched keys and the signing key over the caller's nonce,
+// so the caller can check it is talking to a live enclave. It is not submitted anywhere. The nonce is wrapped in a
+// schema.CallerNonce, otherwise a caller could put a made up freshness nonce on an old payload.
+func (s *Service) Attest(nonce hexutil.Bytes) (*Attestation, error) {
+	if len(nonce) == 0 || len(nonce) > MaxCallerNonceSize {
+		return nil, fmt.Errorf("nonce must be 1 to %d bytes, got %d", MaxCallerNonceSize, len(nonce))
+	}
+	wrapped, err := schema.EncodeCallerNonce(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := s.state.Payload()
+	if payload == nil {
+		return nil, ErrNoPayload
+	}
+
+	document, err := attest.GenerateMockDKIMCBORAttestation(payload, s.signingKey.PublicKey(), wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate attestation: %v", err)
+	}
+	userData, err := attest.DKIMUserData(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Generated attestation over a %d byte caller nonce", len(nonce))
+	return &Attestation{
+		Document:  document,
+		UserData:  userData,
+		Nonce:     wrapped,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// NewHandler serves the JSON-RPC API of service over HTTP. Request bodies above maxRequestSize bytes are refused.
+func NewHandler(service *Service, maxRequestSize int) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	server.SetHTTPBodyLimit(maxRequestSize)
+	if err := server.RegisterName(Namespace, service); err != nil {
+		return nil, fmt.Errorf("failed to register control API: %v", err)
+	}
+	return server, nil
+}
+
+// Serve serves the control API, and the enclave metrics at MetricsPath, on vsockPort until ctx is done.
+func Serve(ctx context.Context, vsockPort uint32, maxRequestSize int, service *Service) error {
+	handler, err := NewHandler(service, maxRequestSize)
+	if err != nil {
+		return err
+	}
+	defer handler.Stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.Handle("GET "+MetricsPath, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	listener, err := vsock.Listen(vsockPort, nil)
+	if err != nil {
+		return fmt.Errorf("control API failed to listen on vsock port %d: %v", vsockPort, err)
+	}
+	traced := tracecontext.Listener(listener)
+
+	// Requests carry the trace context of the caller ahead of them
+	server := &http.Server{Handler: tracecontext.Handler("control", mux), ConnContext: tracecontext.ConnContext}
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		stopped <- server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Control API listening to vsock at port: %v", vsockPort)
+	if err := server.Serve(traced); err != http.ErrServerClosed {
+		return fmt.Errorf("control API stopped: %v", err)
+	}
+
+	if err := <-stopped; err != nil {
+		server.Close()
+		return fmt.Errorf("control API did not finish its requests in time: %w", err)
+	}
+	log.Infof("Control API stopped")
+	return nil
+}
@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultHistorySize is used when Options.HistorySize is not set.
+const DefaultHistorySize = 100
+
+type Options struct {
+	Interval     time.Duration // between the start of successful cycles
+	Jitter       time.Duration // random spread added to or taken from every wait
+	CycleTimeout time.Duration // a cycle is cancelled after this long
+	MinBackoff   time.Duration // wait after the first failed cycle, doubled on every further failure
+	MaxBackoff   time.Duration // never wait longer than Interval or this after a failure
+	HistorySize  int           // cycles kept for status reporting
+}
+
+// Cycle records one run of the refresh function.
+type Cycle struct {
+	Number     uint64    `json:"number"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+	TimedOut   bool      `json:"timed_out,omitempty"`
+	Failures   int       `json:"failures"` // consecutive failed cycles, including this one
+	NextAt     time.Time `json:"next_at"`
+}
+
+func (c *Cycle) Succeeded() bool {
+	return c.Error == ""
+}
+
+// CycleFunc does one refresh. It must give up once ctx is done.
+type CycleFunc func(ctx context.Context) error
+
+// Daemon runs a CycleFunc on a schedule until it is stopped, backing off while cycles fail.
+type Daemon struct {
+	opts  Options
+	cycle CycleFunc
+
+	mu       sync.Mutex
+	history  []Cycle
+	number   uint64
+	failures int
+	trigger  chan struct{}
+}
+
+func New(opts Options, cycle CycleFunc) *Daemon {
+	if opts.HistorySize < 1 {
+		opts.HistorySize = DefaultHistorySize
+	}
+	return &Daemon{opts: opts, cycle: cycle, trigger: make(chan struct{}, 1)}
+}
+
+// Run starts a cycle right away and then one per interval, until ctx is done. It returns ctx's error.
+func (d *Daemon) Run(ctx context.Context) error {
+	log.Infof("Refreshing every %s (±%s), cycle timeout %s", d.opts.Interval, d.opts.Jitter, d.opts.CycleTimeout)
+
+	for {
+		cycle := d.runCycle(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		timer := time.NewTimer(time.Until(cycle.NextAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-d.trigger:
+			timer.Stop()
+			log.Infof("Refresh triggered before the next scheduled cycle")
+		case <-timer.C:
+		}
+	}
+}
+
+// Trigger starts the next cycle without waiting for the schedule. A cycle in progress is not interrupted, the next
+// one starts as soon as it finishes.
+func (d *Daemon) Trigger() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Daemon) runCycle(ctx context.Context) Cycle {
+	d.mu.Lock()
+	d.number++
+	cycle := Cycle{Number: d.number, StartedAt: time.Now()}
+	d.mu.Unlock()
+
+	log.WithField("cycle", cycle.Number).Infof("Starting refresh cycle")
+
+	cycleCtx := ctx
+	if d.opts.CycleTimeout > 0 {
+		var cancel context.CancelFunc
+		cycleCtx, cancel = context.WithTimeout(ctx, d.opts.CycleTimeout)
+		defer cancel()
+	}
+
+	err := d.cycle(cycleCtx)
+	if err == nil && cycleCtx.Err() != nil && ctx.Err() == nil {
+		err = cycleCtx.Err()
+	}
+	cycle.FinishedAt = time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fields := log.Fields{"cycle": cycle.Number, "duration": cycle.FinishedAt.Sub(cycle.StartedAt).Round(time.Millisecond)}
+	if err != nil {
+		d.failures++
+		cycle.Error = err.Error()
+		cycle.TimedOut = errors.Is(err, context.DeadlineExceeded)
+		cycle.Failures = d.failures
+		cycle.NextAt = cycle.FinishedAt.Add(d.backoff(d.failures))
+		fields["failures"] = d.failures
+		fields["timed_out"] = cycle.TimedOut
+		fields["next_at"] = cycle.NextAt.Format(time.RFC3339)
+		log.WithFields(fields).Errorf("Refresh cycle failed: %v", err)
+	} else {
+		d.failures = 0
+		cycle.NextAt = cycle.StartedAt.Add(d.jitter(d.opts.Interval))
+		fields["next_at"] = cycle.NextAt.Format(time.RFC3339)
+		log.WithFields(fields).Infof("Refresh cycle succeeded")
+	}
+
+	d.history = append(d.history, cycle)
+	if len(d.history) > d.opts.HistorySize {
+		d.history = d.history[len(d.history)-d.opts.HistorySize:]
+	}
+	return cycle
+}
+
+// backoff is the wait after the given number of consecutive failures: MinBackoff doubled per failure, capped by
+// MaxBackoff and the interval, with jitter.
+func (d *Daemon) backoff(failures int) time.Duration {
+	limit := d.opts.MaxBackoff
+	if d.opts.Interval > 0 && d.opts.Interval < limit {
+		limit = d.opts.Interval
+	}
+
+	wait := d.opts.MinBackoff
+	for i := 1; i < failures && wait < limit; i++ {
+		wait *= 2
+	}
+	if wait > limit {
+		wait = limit
+	}
+	return d.jitter(wait)
+}
+
+// jitter spreads wait by up to the configured jitter in either direction, never below half of wait.
+func (d *Daemon) jitter(wait time.Duration) time.Duration {
+	spread := d.opts.Jitter
+	if spread > wait/2 {
+		spread = wait / 2
+	}
+	if spread <= 0 {
+		return wait
+	}
+	return wait - spread + time.Duration(rand.Int63n(int64(2*spread)+1))
+}
+
+// History returns the recorded cycles, oldest first.
+func (d *Daemon) History() []Cycle {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Cycle(nil), d.history...)
+}
+
+// LastSuccess returns the most recent successful cycle, if any.
+func (d *Daemon) LastSuccess() (Cycle, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := len(d.history) - 1; i >= 0; i-- {
+		if d.history[i].Succeeded() {
+			return d.history[i], true
+		}
+	}
+	return Cycle{}, false
+}
@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	d := New(Options{Interval: time.Hour, MinBackoff: time.Second, MaxBackoff: 10 * time.Second}, nil)
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(20), "Backoff must be capped")
+
+	d.opts.Interval = 5 * time.Second
+	assert.Equal(t, 5*time.Second, d.backoff(20), "Backoff must not exceed the interval")
+
+	d.opts.Jitter = time.Minute
+	for i := 0; i < 100; i++ {
+		wait := d.backoff(1)
+		assert.True(t, wait >= 500*time.Millisecond && wait <= 1500*time.Millisecond, "Jitter must stay within half the wait: %s", wait)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	d := New(Options{Interval: time.Hour, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, CycleTimeout: 50 * time.Millisecond},
+		func(ctx context.Context) error {
+			calls++
+			switch calls {
+			case 1:
+				return errors.New("source unavailable")
+			case 2:
+				<-ctx.Done()
+				return ctx.Err()
+			case 3:
+				return nil
+			}
+			cancel()
+			return nil
+		})
+
+	done := make(chan error)
+	go func() { done <- d.Run(ctx) }()
+
+	// The third cycle succeeds and waits for the interval, trigger the fourth which stops the daemon
+	assert.Eventually(t, func() bool { _, ok := d.LastSuccess(); return ok }, time.Second, time.Millisecond)
+	d.Trigger()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatalf("Daemon did not stop")
+	}
+
+	history := d.History()
+	assert.Len(t, history, 4)
+	assert.Equal(t, 1, history[0].Failures)
+	assert.True(t, history[1].TimedOut)
+	assert.Equal(t, 2, history[1].Failures)
+	assert.True(t, history[2].Succeeded())
+	assert.Equal(t, 0, history[2].Failures)
+}
@@ -0,0 +1,194 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Default lifetime of an enclave signing key. Once either limit is hit the key is rotated and the new public key has
+// to go through a full attestation validation again.
+const (
+	DefaultMaxKeyAge  = 7 * 24 * time.Hour
+	DefaultMaxUpdates = 1000
+)
+
+type Options struct {
+	MaxAge     time.Duration // rotate the key after this long, 0 means DefaultMaxKeyAge
+	MaxUpdates uint64        // rotate the key after signing this many updates, 0 means DefaultMaxUpdates
+}
+
+// Key is an in-memory secp256k1 signing key generated inside the enclave. The private key never leaves the enclave;
+// its public key is placed in the attestation `public_key` field so that the chain can trust updates signed by it
+// once that attestation has been validated.
+type Key struct {
+	mu sync.Mutex
+
+	opts       Options
+	privateKey *ecdsa.PrivateKey
+	createdAt  time.Time
+
+	// Set once the attestation carrying this key has been accepted on chain.
+	attestationHash common.Hash
+	attestedAt      time.Time
+
+	// Sequence number of the last signed update, updates start at 1.
+	sequence uint64
+}
+
+func NewKey(opts Options) (*Key, error) {
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultMaxKeyAge
+	}
+	if opts.MaxUpdates == 0 {
+		opts.MaxUpdates = DefaultMaxUpdates
+	}
+
+	k := &Key{opts: opts}
+	if err := k.generate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Key) generate() error {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate enclave signing key: %v", err)
+	}
+
+	k.privateKey = privateKey
+	k.createdAt = time.Now()
+	k.attestationHash = common.Hash{}
+	k.attestedAt = time.Time{}
+	k.sequence = 0
+
+	log.Infof("Generated enclave signing key %s", crypto.PubkeyToAddress(privateKey.PublicKey).Hex())
+	return nil
+}
+
+// PublicKey returns the 65 byte uncompressed secp256k1 public key that goes into the attestation `public_key` field.
+func (k *Key) PublicKey() []byte {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return crypto.FromECDSAPub(&k.privateKey.PublicKey)
+}
+
+// Address returns the Ethereum address derived from the public key, which is what contracts recover signatures to.
+func (k *Key) Address() common.Address {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return crypto.PubkeyToAddress(k.privateKey.PublicKey)
+}
+
+// Bind records that the attestation carrying this key has been accepted on chain. Signed updates reference the hash
+// of that attestation so verifiers can look up which attested key they must be checked against.
+func (k *Key) Bind(attestation []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.attestationHash = crypto.Keccak256Hash(attestation)
+	k.attestedAt = time.Now()
+	log.Infof("Enclave signing key bound to attestation %s", k.attestationHash.Hex())
+}
+
+// IsBound reports whether the key has been bound to an accepted attestation.
+func (k *Key) IsBound() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.attestationHash != (common.Hash{})
+}
+
+func (k *Key) AttestationHash() common.Hash {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.attestationHash
+}
+
+// NeedsReattestation reports whether the next key set has to be published through a full attestation instead of a
+// signed update, either because the key was never bound or because it has reached the end of its lifetime.
+func (k *Key) NeedsReattestation(now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.attestationHash == (common.Hash{}) || k.expired(now)
+}
+
+func (k *Key) expired(now time.Time) bool {
+	return now.Sub(k.createdAt) >= k.opts.MaxAge || k.sequence >= k.opts.MaxUpdates
+}
+
+// Rotate replaces the key with a freshly generated one. The new key is unbound and must be attested before it can
+// sign updates.
+func (k *Key) Rotate() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	log.Infof("Rotating enclave signing key after %d updates", k.sequence)
+	return k.generate()
+}
+
+// SignUpdate wraps the payload into a KeyUpdate with the next sequence number and signs it.
+func (k *Key) SignUpdate(payload []byte) (*SignedUpdate, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.attestationHash == (common.Hash{}) {
+		return nil, fmt.Errorf("enclave signing key is not bound to an attestation")
+	}
+	if k.expired(time.Now()) {
+		return nil, fmt.Errorf("enclave signing key has expired, re-attestation required")
+	}
+
+	update := KeyUpdate{
+		Version:         UpdateVersion,
+		Sequence:        k.sequence + 1,
+		AttestationHash: k.attestationHash,
+		IssuedAt:        uint64(time.Now().Unix()),
+		Payload:         payload,
+	}
+
+	digest, err := update.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := crypto.Sign(digest.Bytes(), k.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign key update: %v", err)
+	}
+	// Solidity's ecrecover expects v to be 27 or 28
+	signature[crypto.RecoveryIDOffset] += 27
+
+	k.sequence = update.Sequence
+	return &SignedUpdate{KeyUpdate: update, Signature: signature}, nil
+}
+
+// Signature is a digest signed by the enclave key, with the key it was signed with.
+type Signature struct {
+	PublicKey       []byte      // 65 byte uncompressed public key
+	AttestationHash common.Hash // attestation that bound the key, zero if it was not bound yet
+	Signature       []byte      // 65 bytes r || s || v with v in {27, 28}
+}
+
+// Sign signs an arbitrary digest without using up an update sequence number. Callers must hash a domain separator
+// into the digest so it cannot be mistaken for a key update.
+func (k *Key) Sign(digest common.Hash) (*Signature, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	signature, err := crypto.Sign(digest.Bytes(), k.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %v", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return &Signature{
+		PublicKey:       crypto.FromECDSAPub(&k.privateKey.PublicKey),
+		AttestationHash: k.attestationHash,
+		Signature:       signature,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package identity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedUpdateRoundTrip(t *testing.T) {
+	key, err := NewKey(Options{})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	_, err = key.SignUpdate([]byte("payload"))
+	assert.Error(t, err, "An unbound key must not sign updates")
+
+	key.Bind([]byte("attestation"))
+	assert.False(t, key.NeedsReattestation(time.Now()))
+
+	update, err := key.SignUpdate([]byte("payload"))
+	if err != nil {
+		t.Fatalf("Failed to sign update: %v", err)
+	}
+	assert.Equal(t, uint64(1), update.Sequence)
+	assert.NoError(t, update.Verify(key.PublicKey()))
+
+	encoded, err := update.Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode update: %v", err)
+	}
+
+	decoded, err := DecodeSignedUpdate(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode update: %v", err)
+	}
+	assert.Equal(t, update, decoded)
+	assert.NoError(t, decoded.Verify(key.PublicKey()))
+
+	decoded.Payload = []byte("tampered")
+	assert.Error(t, decoded.Verify(key.PublicKey()))
+}
+
+func TestKeyLifetime(t *testing.T) {
+	key, err := NewKey(Options{MaxUpdates: 2})
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	key.Bind([]byte("attestation"))
+
+	for i := 0; i < 2; i++ {
+		_, err := key.SignUpdate([]byte("payload"))
+		assert.NoError(t, err)
+	}
+	assert.True(t, key.NeedsReattestation(time.Now()))
+
+	_, err = key.SignUpdate([]byte("payload"))
+	assert.Error(t, err)
+
+	oldPubKey := key.PublicKey()
+	assert.NoError(t, key.Rotate())
+	assert.NotEqual(t, oldPubKey, key.PublicKey())
+	assert.False(t, key.IsBound())
+}
@@ -0,0 +1,149 @@
+package identity
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// UpdateVersion is the version of the signed update format produced by this package.
+const UpdateVersion = 1
+
+// updateDomain separates key update signatures from any other message the enclave key might sign.
+var updateDomain = crypto.Keccak256Hash([]byte("TeeGoogle.KeyUpdate"))
+
+// KeyUpdate is a key set published with the enclave signing key instead of a full attestation. Payload has the same
+// format as the attestation user_data it replaces.
+type KeyUpdate struct {
+	Version         uint8
+	Sequence        uint64
+	AttestationHash common.Hash // keccak256 of the attestation that bound the signing key
+	IssuedAt        uint64      // unix seconds
+	Payload         []byte
+}
+
+type SignedUpdate struct {
+	KeyUpdate
+	Signature []byte // 65 bytes r || s || v with v in {27, 28}
+}
+
+var (
+	bytes32Type, _ = abi.NewType("bytes32", "", nil)
+	uint8Type, _   = abi.NewType("uint8", "", nil)
+	uint64Type, _  = abi.NewType("uint64", "", nil)
+	bytesType, _   = abi.NewType("bytes", "", nil)
+
+	// abi.encode(domain, version, sequence, attestationHash, issuedAt, keccak256(payload))
+	digestArgs = abi.Arguments{
+		{Type: bytes32Type}, {Type: uint8Type}, {Type: uint64Type}, {Type: bytes32Type}, {Type: uint64Type}, {Type: bytes32Type},
+	}
+
+	// abi.encode(version, sequence, attestationHash, issuedAt, payload, signature)
+	encodedArgs = abi.Arguments{
+		{Type: uint8Type}, {Type: uint64Type}, {Type: bytes32Type}, {Type: uint64Type}, {Type: bytesType}, {Type: bytesType},
+	}
+)
+
+// Digest returns the EIP-191 personal message hash of the update, which is what the enclave key signs. It can be
+// recomputed in Solidity with
+//
+//	MessageHashUtils.toEthSignedMessageHash(keccak256(abi.encode(domain, version, sequence, attestationHash, issuedAt, keccak256(payload))))
+func (u *KeyUpdate) Digest() (common.Hash, error) {
+	packed, err := digestArgs.Pack(
+		updateDomain,
+		u.Version,
+		u.Sequence,
+		u.AttestationHash,
+		u.IssuedAt,
+		crypto.Keccak256Hash(u.Payload),
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode key update: %v", err)
+	}
+	return common.BytesToHash(accounts.TextHash(crypto.Keccak256(packed))), nil
+}
+
+// Encode ABI encodes the signed update so it can be passed as a single `bytes` argument to a contract.
+func (s *SignedUpdate) Encode() ([]byte, error) {
+	encoded, err := encodedArgs.Pack(
+		s.Version,
+		s.Sequence,
+		s.AttestationHash,
+		s.IssuedAt,
+		s.Payload,
+		s.Signature,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signed update: %v", err)
+	}
+	return encoded, nil
+}
+
+func DecodeSignedUpdate(data []byte) (*SignedUpdate, error) {
+	values, err := encodedArgs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signed update: %v", err)
+	}
+
+	return &SignedUpdate{
+		KeyUpdate: KeyUpdate{
+			Version:         values[0].(uint8),
+			Sequence:        values[1].(uint64),
+			AttestationHash: common.Hash(values[2].([32]byte)),
+			IssuedAt:        values[3].(uint64),
+			Payload:         values[4].([]byte),
+		},
+		Signature: values[5].([]byte),
+	}, nil
+}
+
+// Signer recovers the address that signed the update.
+func (s *SignedUpdate) Signer() (common.Address, error) {
+	if len(s.Signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(s.Signature))
+	}
+
+	digest, err := s.Digest()
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	signature := bytes.Clone(s.Signature)
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(digest.Bytes(), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// Verify checks that the update was signed by the holder of the attested public key.
+func (s *SignedUpdate) Verify(attestedPubKey []byte) error {
+	pubKey, err := crypto.UnmarshalPubkey(attestedPubKey)
+	if err != nil {
+		return fmt.Errorf("invalid attested public key: %v", err)
+	}
+
+	signer, err := s.Signer()
+	if err != nil {
+		return err
+	}
+
+	if signer != crypto.PubkeyToAddress(*pubKey) {
+		return fmt.Errorf("update signed by %s, expected %s", signer.Hex(), crypto.PubkeyToAddress(*pubKey).Hex())
+	}
+	return nil
+}
+
+// String is used for logging, it leaves out the payload.
+func (s *SignedUpdate) String() string {
+	return fmt.Sprintf("KeyUpdate{version: %d, sequence: %d, attestation: %s, issuedAt: %d, payload: %d bytes}",
+		s.Version, s.Sequence, s.AttestationHash.Hex(), s.IssuedAt, len(s.Payload))
+}
@@ -0,0 +1,224 @@
+package logstream
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
+	"github.com/EkamSinghPandher/Tee-Google/vsock"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxEntrySize is the largest encoded entry that is streamed, larger ones are dropped. The host collector refuses
+// longer lines.
+const MaxEntrySize = 64 << 10
+
+// Reconnect backoff and the time a write to the host may take before the connection is given up.
+var (
+	MinBackoff   = 100 * time.Millisecond
+	MaxBackoff   = 10 * time.Second
+	WriteTimeout = 5 * time.Second
+)
+
+// Streamer is a logrus hook that sends every entry as a JSON line to the host. There is no console in a Nitro
+// enclave, so this is the only way the logs get out. Entries are buffered while the host is unavailable and dropped,
+// and counted, once the buffer is full. An entry whose write failed part way is dropped too: the collector rejects the
+// cut off line, so it is not sent again. Logging never blocks on the host.
+type Streamer struct {
+	dial      func() (net.Conn, error)
+	formatter log.Formatter
+	entries   chan []byte
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+
+	stop    context.CancelFunc
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// New streams to whatever dial connects to, keeping up to bufferSize entries. Start must be called to begin sending.
+func New(dial func() (net.Conn, error), bufferSize int) *Streamer {
+	return &Streamer{
+		dial:      dial,
+		formatter: &log.JSONFormatter{TimestampFormat: time.RFC3339Nano},
+		entries:   make(chan []byte, bufferSize),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Init adds a streamer to vsockPort of the host hostCID to the standard logger and starts it.
+func Init(hostCID uint32, vsockPort uint32, bufferSize int) *Streamer {
+	streamer := New(func() (net.Conn, error) {
+		return vsock.Dial(hostCID, vsockPort, nil)
+	}, bufferSize)
+	log.AddHook(streamer)
+	streamer.Start()
+	log.Infof("Streaming logs to vsock port %d of host %d", vsockPort, hostCID)
+	return streamer
+}
+
+func (s *Streamer) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire encodes the entry and queues it, or drops it if the buffer is full.
+func (s *Streamer) Fire(entry *log.Entry) error {
+	line, err := s.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	if len(line) > MaxEntrySize {
+		s.drop()
+		return nil
+	}
+
+	select {
+	case s.entries <- line:
+	default:
+		s.drop()
+	}
+	return nil
+}
+
+func (s *Streamer) drop() {
+	s.dropped.Add(1)
+	metrics.LogEntriesDropped.Inc()
+}
+
+// Sent and Dropped count the entries delivered to the host and the ones lost.
+func (s *Streamer) Sent() uint64    { return s.sent.Load() }
+func (s *Streamer) Dropped() uint64 { return s.dropped.Load() }
+
+// Start sends the queued entries in the background until Close.
+func (s *Streamer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	go s.run(ctx)
+}
+
+// Close stops sending once the buffered entries are out, or once timeout has passed.
+func (s *Streamer) Close(timeout time.Duration) {
+	s.once.Do(func() {
+		deadline := time.Now().Add(timeout)
+		for len(s.entries) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		s.stop()
+		<-s.stopped
+	})
+}
+
+func (s *Streamer) run(ctx context.Context) {
+	defer close(s.stopped)
+
+	var (
+		conn     net.Conn
+		pending  []byte // taken from the buffer but not written yet
+		failures int
+		reported uint64 // drops already reported to the host
+	)
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		if pending == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case pending = <-s.entries:
+			}
+		}
+
+		if conn == nil {
+			var err error
+			conn, err = s.dial()
+			if err != nil {
+				conn = nil
+				failures++
+				if failures == 1 {
+					// Logging this would queue yet another entry for the host that cannot be reached
+					fmt.Fprintf(os.Stderr, "Log stream to host unavailable, buffering: %v\n", err)
+				}
+				if !sleep(ctx, backoff(failures)) {
+					return
+				}
+				continue
+			}
+			failures = 0
+		}
+
+		// Tell the collector how many entries it missed since the last report
+		if dropped := s.dropped.Load(); dropped > reported {
+			notice, _ := s.formatter.Format(&log.Entry{
+				Logger:  log.StandardLogger(),
+				Data:    log.Fields{"dropped_total": dropped, "dropped": dropped - reported},
+				Time:    time.Now(),
+				Level:   log.WarnLevel,
+				Message: "Log entries were dropped while the host was unavailable",
+			})
+			written, err := s.write(conn, notice)
+			if written > 0 {
+				// Cut off or not, a notice is never sent twice, the next one carries the total
+				reported = dropped
+			}
+			if err != nil {
+				conn.Close()
+				conn = nil
+				continue
+			}
+		}
+
+		if written, err := s.write(conn, pending); err != nil {
+			conn.Close()
+			conn = nil
+			if written > 0 {
+				// The collector got the start of the line, sent again it would arrive cut off and then whole
+				pending = nil
+				s.drop()
+			}
+			continue
+		}
+		pending = nil
+		s.sent.Add(1)
+		metrics.LogEntriesSent.Inc()
+	}
+}
+
+// write sends line and returns how much of it was written.
+func (s *Streamer) write(conn net.Conn, line []byte) (int, error) {
+	conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	return conn.Write(line)
+}
+
+func backoff(failures int) time.Duration {
+	wait := MinBackoff
+	for i := 1; i < failures && wait < MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > MaxBackoff {
+		wait = MaxBackoff
+	}
+	return wait
+}
+
+// sleep waits for d and reports false if ctx was done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,143 @@
+package logstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamerBuffersAndCountsDrops(t *testing.T) {
+	minBackoff := MinBackoff
+	MinBackoff = 10 * time.Millisecond
+	defer func() { MinBackoff = minBackoff }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// The host is unavailable until available is closed
+	available := make(chan struct{})
+	streamer := New(func() (net.Conn, error) {
+		select {
+		case <-available:
+			return net.Dial("tcp", listener.Addr().String())
+		default:
+			return nil, errors.New("connection refused")
+		}
+	}, 2)
+	logger := log.New()
+	logger.AddHook(streamer)
+	streamer.Start()
+	defer streamer.Close(time.Second)
+
+	// One entry waits for the host in the sender, two in the buffer, the rest is dropped
+	for i := 0; i < 5; i++ {
+		logger.WithField("i", i).Info("refresh")
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(2), streamer.Dropped())
+
+	close(available)
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Failed to accept: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	lines := bufio.NewScanner(conn)
+
+	var entries []map[string]interface{}
+	for len(entries) < 4 && lines.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			t.Fatalf("Streamed line is not JSON: %q", lines.Text())
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) < 4 {
+		t.Fatalf("Expected 4 lines, got %d: %v", len(entries), lines.Err())
+	}
+
+	assert.Equal(t, float64(2), entries[0]["dropped"], "The collector must be told about the dropped entries first")
+	for i, entry := range entries[1:] {
+		assert.Equal(t, "refresh", entry["msg"])
+		assert.Equal(t, float64(i), entry["i"], "Buffered entries must arrive in order")
+	}
+	assert.Eventually(t, func() bool { return streamer.Sent() == 3 }, time.Second, 10*time.Millisecond)
+}
+
+// cutConn writes only the first half of its first write, as a write timing out part way would.
+type cutConn struct {
+	net.Conn
+	cut bool
+}
+
+func (c *cutConn) Write(b []byte) (int, error) {
+	if c.cut {
+		return c.Conn.Write(b)
+	}
+	c.cut = true
+	n, _ := c.Conn.Write(b[:len(b)/2])
+	return n, os.ErrDeadlineExceeded
+}
+
+func TestStreamerDropsCutOffEntries(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	dials := 0
+	streamer := New(func() (net.Conn, error) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		dials++
+		if dials == 1 {
+			return &cutConn{Conn: conn}, nil
+		}
+		return conn, nil
+	}, 10)
+	logger := log.New()
+	logger.AddHook(streamer)
+
+	logger.WithField("i", 0).Info("refresh")
+	logger.WithField("i", 1).Info("refresh")
+	streamer.Start()
+	defer streamer.Close(time.Second)
+
+	// What the collector reads from each connection in turn
+	var lines []string
+	for len(lines) < 3 {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("Failed to accept: %v", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		scanner := bufio.NewScanner(conn)
+		for len(lines) < 3 && scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		conn.Close()
+	}
+
+	assert.False(t, json.Valid([]byte(lines[0])), "The first connection only got the start of entry 0")
+	var notice, entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &notice))
+	assert.Equal(t, float64(1), notice["dropped"], "The cut off entry must be counted as dropped")
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &entry))
+	assert.Equal(t, float64(1), entry["i"], "The cut off entry must not be sent again")
+	assert.Equal(t, uint64(1), streamer.Dropped())
+	assert.Eventually(t, func() bool { return streamer.Sent() == 1 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry holds the enclave metrics. The host serves them next to its own, so the enclave's Go runtime and process
+// metrics are prefixed with enclave_ to keep them apart from the host's.
+var Registry = prometheus.NewRegistry()
+
+var (
+	FetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "enclave_fetch_duration_seconds",
+		Help:    "Time to fetch keys from a source: the JWKS URL or a DKIM DNS name.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"source", "result"})
+
+	Keys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "enclave_keys",
+		Help: "Keys in the last successful fetch, by kind (jwks or dkim).",
+	}, []string{"kind"})
+
+	AttestationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "enclave_attestation_generation_seconds",
+		Help:    "Time to generate an attestation document for a submission.",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	})
+
+	Submissions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "enclave_submissions_total",
+		Help: "Submissions by chain, kind (attestation or signed_update) and final status.",
+	}, []string{"chain", "kind", "status"})
+
+	GasUsed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "enclave_submission_gas_used_total",
+		Help: "Gas used by mined submissions, by chain and kind.",
+	}, []string{"chain", "kind"})
+
+	StaleKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "enclave_stale_keys",
+		Help: "Fetched DKIM keys the registry holds an older value for and will not overwrite, by chain.",
+	}, []string{"chain"})
+
+	LastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "enclave_last_success_timestamp_seconds",
+		Help: "Unix time the last refresh cycle succeeded.",
+	})
+
+	LogEntriesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "enclave_log_entries_sent_total",
+		Help: "Log entries streamed to the host.",
+	})
+
+	LogEntriesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "enclave_log_entries_dropped_total",
+		Help: "Log entries dropped because the buffer was full while the host was unavailable, or they were too large.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(FetchDuration, Keys, AttestationDuration, Submissions, GasUsed, StaleKeys, LastSuccess,
+		LogEntriesSent, LogEntriesDropped)
+
+	runtime := prometheus.WrapRegistererWithPrefix("enclave_", Registry)
+	runtime.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
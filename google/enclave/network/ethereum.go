@@ -2,21 +2,33 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"math/big"
 	"net/http"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
 )
 
-var ethereumClient *ethclient.Client
-
-func InitEthereumClientWithVsockTransport(vsockPort uint32) error {
-
-	transport := &VsockHTTPRoundTripper{
-		CID:  3, // Host CID
+// NewEthereumClientWithVsockTransport dials rpcURL through the proxy of host hostCID listening on vsockPort. The host decides
+// where the traffic actually goes, rpcURL only determines the request path and Host header, and whether TLS is spoken
+// end to end with the node.
+func NewEthereumClientWithVsockTransport(hostCID uint32, vsockPort uint32, rpcURL string) (*ethclient.Client, error) {
+	var transport http.RoundTripper = &VsockHTTPRoundTripper{
+		CID:  hostCID,
 		Port: vsockPort,
 	}
+	if strings.HasPrefix(rpcURL, "https://") {
+		transport = &VsockTLSRoundTripper{
+			CID:       hostCID,
+			Port:      vsockPort,
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+	}
 
 	httpClient := &http.Client{
 		Transport: transport,
@@ -24,18 +36,59 @@ func InitEthereumClientWithVsockTransport(vsockPort uint32) error {
 
 	rpcClient, err := rpc.DialOptions(
 		context.Background(),
-		"http://127.0.0.1:8545",
+		rpcURL,
 		rpc.WithHTTPClient(httpClient),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ethereumClient = ethclient.NewClient(rpcClient)
-	log.Infof("Ethereum client initialized with VSock transport on port %d", vsockPort)
-	return nil
+	log.Infof("Ethereum client for %s initialized with VSock transport on port %d", rpcURL, vsockPort)
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// ChainAnchor is a recent block the enclave binds its attestation to
+type ChainAnchor struct {
+	ChainID *big.Int
+	Number  uint64
+	Hash    common.Hash
+	Time    uint64
 }
 
-func GetEthereumClient() *ethclient.Client {
-	return ethereumClient
+// GetChainAnchor fetches the latest block header and fetches it again by its hash, checking that both answers agree.
+// This only guards against an inconsistent RPC, the enclave does not verify the header itself: both answers come from
+// the same node through the host proxy, which could serve a made-up block. Verifiers must check the block hash in the
+// nonce against a node they trust.
+func GetChainAnchor(ctx context.Context, ethereumClient *ethclient.Client) (*ChainAnchor, error) {
+	if ethereumClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	chainID, err := ethereumClient.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	latest, err := ethereumClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block header: %v", err)
+	}
+
+	hash := latest.Hash()
+	byHash, err := ethereumClient.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header %s: %v", hash.Hex(), err)
+	}
+	if byHash.Hash() != hash || byHash.Number.Cmp(latest.Number) != 0 {
+		return nil, fmt.Errorf("block header mismatch for block %s", latest.Number)
+	}
+
+	log.Infof("Anchoring attestation to block %d (%s)", latest.Number.Uint64(), hash.Hex())
+
+	return &ChainAnchor{
+		ChainID: chainID,
+		Number:  latest.Number.Uint64(),
+		Hash:    hash,
+		Time:    latest.Time,
+	}, nil
 }
@@ -1,7 +1,9 @@
 package network
 
 import (
+	"context"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -9,18 +11,43 @@ import (
 	"io"
 	"math/big"
 	"net"
+	"net/http"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/metrics"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const googleJwksUrl = "https://www.googleapis.com/oauth2/v3/certs"
-
 // Combined response structure
 type GoogleKeys struct {
 	JWKSKeys map[string]*rsa.PublicKey            `json:"jwks_keys"`
 	DKIMKeys map[string]map[string]*rsa.PublicKey `json:"dkim_keys"`
+
+	JWKSAlgorithms map[string]string               `json:"jwks_algorithms"` // kid -> alg
+	JWKSEvidence   *Evidence                       `json:"jwks_evidence"`
+	DKIMEvidence   map[string]map[string]*Evidence `json:"dkim_evidence"` // domain -> selector -> evidence
+}
+
+// Evidence records where and when keys were fetched, together with the sha256 of the raw response they were parsed from
+type Evidence struct {
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Hash      []byte    `json:"hash"`
+}
+
+func newEvidence(source string, raw []byte) *Evidence {
+	hash := sha256.Sum256(raw)
+	return &Evidence{
+		Source:    source,
+		FetchedAt: time.Now().UTC(),
+		Hash:      hash[:],
+	}
 }
 
 // JWK represents a JSON Web Key
@@ -74,24 +101,25 @@ type JWKSResponse struct {
 }
 
 // Get google RSA pubkeys from their endpoint
-func GetGoogleKeys() (*GoogleKeys, error) {
+func GetGoogleKeys(ctx context.Context) (_ *GoogleKeys, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GetGoogleKeys")
+	defer func() { tracing.End(span, err) }()
+
 	result := &GoogleKeys{
-		JWKSKeys: make(map[string]*rsa.PublicKey),
-		DKIMKeys: make(map[string]map[string]*rsa.PublicKey),
+		JWKSKeys:       make(map[string]*rsa.PublicKey),
+		DKIMKeys:       make(map[string]map[string]*rsa.PublicKey),
+		JWKSAlgorithms: make(map[string]string),
+		DKIMEvidence:   make(map[string]map[string]*Evidence),
 	}
 
-	jwksKeys, err := getJWKSKeys()
+	err = getJWKSKeys(ctx, result)
 	if err != nil {
 		log.Errorf("Error fetching JWKS keys: %v", err)
-	} else {
-		result.JWKSKeys = jwksKeys
 	}
 
-	dkimKeys, err := getDKIMKeys()
+	err = getDKIMKeys(ctx, result)
 	if err != nil {
 		log.Errorf("Error fetching DKIM keys: %v", err)
-	} else {
-		result.DKIMKeys = dkimKeys
 	}
 
 	if len(result.JWKSKeys) == 0 && len(result.DKIMKeys) == 0 {
@@ -106,21 +134,28 @@ func GetGoogleKeys() (*GoogleKeys, error) {
 
 	log.Infof("Successfully fetched %d JWKS keys and %d DKIM keys",
 		len(result.JWKSKeys), total_dkim_keys)
+	metrics.Keys.WithLabelValues("jwks").Set(float64(len(result.JWKSKeys)))
+	metrics.Keys.WithLabelValues("dkim").Set(float64(total_dkim_keys))
 
 	return result, nil
 }
 
-func getDKIMKeys() (map[string]map[string]*rsa.PublicKey, error) {
-	// Gmail DKIM selectors to try
-	selectors := []string{"20230601"}
-	domain := "gmail.com"
+func getDKIMKeys(ctx context.Context, result *GoogleKeys) error {
+	// DKIM selectors to try
+	selectors := googleConfig.DKIMSelectors
+	domain := googleConfig.DKIMDomain
 
 	keys := make(map[string]map[string]*rsa.PublicKey)
+	evidence := make(map[string]map[string]*Evidence)
 
 	for _, selector := range selectors {
 		dkimDomain := fmt.Sprintf("%s._domainkey.%s", selector, domain)
 
-		txtRecords, err := net.LookupTXT(dkimDomain)
+		started := time.Now()
+		lookupCtx, span := tracing.Tracer.Start(ctx, "lookup DKIM record", trace.WithAttributes(attribute.String("dns.name", dkimDomain)))
+		txtRecords, err := net.DefaultResolver.LookupTXT(lookupCtx, dkimDomain)
+		tracing.End(span, err)
+		observeFetch(dkimDomain, started, err)
 
 		if err != nil {
 			log.Warnf("DNS lookup failed for %s: %v", dkimDomain, err)
@@ -139,19 +174,23 @@ func getDKIMKeys() (map[string]map[string]*rsa.PublicKey, error) {
 
 				if keys[domain] == nil {
 					keys[domain] = make(map[string]*rsa.PublicKey)
+					evidence[domain] = make(map[string]*Evidence)
 				}
 
 				keys[domain][selector] = pubKey
+				evidence[domain][selector] = newEvidence(dkimDomain, []byte(record))
 				break
 			}
 		}
 	}
 
 	if len(keys) == 0 {
-		return nil, fmt.Errorf("no valid DKIM keys found for gmail.com")
+		return fmt.Errorf("no valid DKIM keys found for %s", domain)
 	}
 
-	return keys, nil
+	result.DKIMKeys = keys
+	result.DKIMEvidence = evidence
+	return nil
 }
 
 func parseDKIMRecord(record string) (*rsa.PublicKey, error) {
@@ -186,31 +225,45 @@ func parseDKIMRecord(record string) (*rsa.PublicKey, error) {
 	return rsaPubKey, nil
 }
 
-func getJWKSKeys() (map[string]*rsa.PublicKey, error) {
-	resp, err := googleClient.Get(googleJwksUrl)
+func getJWKSKeys(ctx context.Context, result *GoogleKeys) (err error) {
+	jwksURL := googleConfig.JWKSURL
+	ctx, span := tracing.Tracer.Start(ctx, "fetch JWKS", trace.WithAttributes(attribute.String("url.full", jwksURL)))
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+
+	started := time.Now()
+	resp, err := googleClient.Do(req)
 	if err != nil {
+		observeFetch(jwksURL, started, err)
 		log.Errorf("Error fetching google cert with err: %+v", err)
-		return nil, fmt.Errorf("error fetching keys from google: %v", err)
+		return fmt.Errorf("error fetching keys from google: %v", err)
 	}
 
 	defer resp.Body.Close()
 
 	// Read the response body
 	body, err := io.ReadAll(resp.Body)
+	observeFetch(jwksURL, started, err)
 	if err != nil {
 		log.Errorf("Error reading response body: %v", err)
-		return nil, fmt.Errorf("error reading response body: %v", err)
+		return fmt.Errorf("error reading response body: %v", err)
 	}
+	evidence := newEvidence(jwksURL, body)
 
 	// Parse the JWKS response
 	var jwks JWKSResponse
 	if err := json.Unmarshal(body, &jwks); err != nil {
 		log.Errorf("error parsing JWKS: %v", err)
-		return nil, fmt.Errorf("error parsing JWKS: %v", err)
+		return fmt.Errorf("error parsing JWKS: %v", err)
 	}
 
 	// Create a map to store public keys by kid
 	keys := make(map[string]*rsa.PublicKey)
+	algorithms := make(map[string]string)
 
 	// Convert JWKs to RSA public keys
 	for _, jwk := range jwks.Keys {
@@ -220,8 +273,21 @@ func getJWKSKeys() (map[string]*rsa.PublicKey, error) {
 			continue
 		} else {
 			keys[jwk.Kid] = pubKey
+			algorithms[jwk.Kid] = jwk.Alg
 		}
 	}
 
-	return keys, nil
+	result.JWKSKeys = keys
+	result.JWKSAlgorithms = algorithms
+	result.JWKSEvidence = evidence
+	return nil
+}
+
+// observeFetch records how long fetching from source took since started.
+func observeFetch(source string, started time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.FetchDuration.WithLabelValues(source, result).Observe(time.Since(started).Seconds())
 }
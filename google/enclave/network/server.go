@@ -4,20 +4,29 @@ import (
 	"crypto/tls"
 	"net/http"
 
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+
 	log "github.com/sirupsen/logrus"
 )
 
-var googleClient *http.Client
+var (
+	googleClient *http.Client
+	googleConfig = config.Default().Google
+)
+
+// InitGoogleHttpsClientWithTLSVsockTransport creates an HTTP client that uses TLS over VSock to the Google proxy of
+// host hostCID, and fetches keys from the sources in google.
+func InitGoogleHttpsClientWithTLSVsockTransport(hostCID uint32, google config.GoogleConfig) {
+	googleConfig = google
+	vsockPort := google.VsockPort
 
-// InitGoogleHttpsClientWithTLSVsockTransport creates an HTTP client that uses TLS over VSock
-func InitGoogleHttpsClientWithTLSVsockTransport(vsockPort uint32) {
 	tlsConfig := &tls.Config{
 		MinVersion: tls.VersionTLS12,
-		ServerName: "www.googleapis.com",
+		ServerName: google.Host,
 	}
 
 	transport := &VsockTLSRoundTripper{
-		CID:       3, // Host CID
+		CID:       hostCID,
 		Port:      vsockPort,
 		TLSConfig: tlsConfig,
 	}
@@ -3,9 +3,13 @@ package network
 import (
 	"bufio"
 	"crypto/tls"
+	"io"
+	"net"
 	"net/http"
 
 	"github.com/EkamSinghPandher/Tee-Google/vsock"
+	"github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -32,26 +36,34 @@ func (v *VsockTLSRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 		return nil, err
 	}
 
-	// Set ServerName based on the request's host if not already set
-	if v.TLSConfig.ServerName == "" {
-		v.TLSConfig = v.TLSConfig.Clone()
-		v.TLSConfig.ServerName = req.URL.Host
+	// The host proxy joins its connection span to the trace of the request
+	if err := tracecontext.Write(req.Context(), conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Set ServerName based on the request's host if not already set. The round tripper is shared by concurrent
+	// requests, so the config is cloned for this connection only
+	tlsConfig := v.TLSConfig
+	if tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = req.URL.Hostname()
 	}
 
 	// Create TLS connection
-	tlsConn := tls.Client(conn, v.TLSConfig)
-	defer tlsConn.Close()
+	tlsConn := tls.Client(conn, tlsConfig)
 
 	// Perform TLS handshake
 	if err := tlsConn.Handshake(); err != nil {
 		log.Errorf("TLS handshake failed: %v", err)
-		conn.Close()
+		tlsConn.Close()
 		return nil, err
 	}
 
 	// Send HTTP request over TLS connection
 	if err := req.Write(tlsConn); err != nil {
 		log.Errorf("Failed to write request over TLS: %v", err)
+		tlsConn.Close()
 		return nil, err
 	}
 
@@ -59,13 +71,17 @@ func (v *VsockTLSRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 	resp, err := http.ReadResponse(bufio.NewReader(tlsConn), req)
 	if err != nil {
 		log.Errorf("Failed to read response over TLS: %v", err)
+		tlsConn.Close()
 		return nil, err
 	}
 
+	// The body is read from the connection, keep it open until the caller is done with the body
+	resp.Body = &connClosingBody{ReadCloser: resp.Body, conn: tlsConn}
 	return resp, nil
 }
 
-// VsockHTTPRoundTripper is a custom RoundTripper for plain HTTP over VSock (no TLS)
+// VsockHTTPRoundTripper is a custom RoundTripper for plain HTTP over VSock (no TLS). Like VsockTLSRoundTripper it sends
+// the trace context of the request ahead of it, so the listener must strip it with tracecontext.Listener.
 type VsockHTTPRoundTripper struct {
 	CID  uint32
 	Port uint32
@@ -78,11 +94,16 @@ func (v *VsockHTTPRoundTripper) RoundTrip(req *http.Request) (*http.Response, er
 		log.Errorf("Unable to connect to vsock port %d: %v", v.Port, err)
 		return nil, err
 	}
-	defer conn.Close()
+
+	if err := tracecontext.Write(req.Context(), conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
 
 	// Send HTTP request directly over VSock (no TLS)
 	if err := req.Write(conn); err != nil {
 		log.Errorf("Failed to write request over VSock: %v", err)
+		conn.Close()
 		return nil, err
 	}
 
@@ -90,8 +111,22 @@ func (v *VsockHTTPRoundTripper) RoundTrip(req *http.Request) (*http.Response, er
 	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
 	if err != nil {
 		log.Errorf("Failed to read response over VSock: %v", err)
+		conn.Close()
 		return nil, err
 	}
 
+	// The body is read from the connection, keep it open until the caller is done with the body
+	resp.Body = &connClosingBody{ReadCloser: resp.Body, conn: conn}
 	return resp, nil
 }
+
+type connClosingBody struct {
+	io.ReadCloser
+	conn net.Conn
+}
+
+func (b *connClosingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+	return err
+}
@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ErrNonCanonical is returned when the input is valid CBOR but not in Core Deterministic Encoding, e.g. unsorted map
+// keys, non-shortest integers or indefinite length items.
+var ErrNonCanonical = errors.New("cbor input is not in core deterministic encoding")
+
+var (
+	// RFC 8949 section 4.2.1 Core Deterministic Encoding: shortest integer and length encodings, map keys sorted
+	// bytewise lexicographic, no indefinite lengths.
+	encMode cbor.EncMode
+
+	decMode cbor.DecMode
+)
+
+func init() {
+	var err error
+
+	encMode, err = cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("failed to create canonical cbor encoder: %v", err))
+	}
+
+	decMode, err = cbor.DecOptions{
+		DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+		IndefLength:       cbor.IndefLengthForbidden,
+		UTF8:              cbor.UTF8RejectInvalid,
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+	if err != nil {
+		panic(fmt.Sprintf("failed to create canonical cbor decoder: %v", err))
+	}
+}
+
+// Marshal encodes v with Core Deterministic Encoding, so equal values always produce identical bytes and verifiers can
+// recompute hashes over them.
+func Marshal(v interface{}) ([]byte, error) {
+	return encMode.Marshal(v)
+}
+
+// Unmarshal decodes canonical CBOR into v. Input that is not in Core Deterministic Encoding, has duplicate map keys,
+// trailing bytes or fields unknown to v is rejected.
+func Unmarshal(data []byte, v interface{}) error {
+	if err := CheckCanonical(data); err != nil {
+		return err
+	}
+	return decMode.Unmarshal(data, v)
+}
+
+// CheckCanonical verifies that data is a single well formed CBOR item in Core Deterministic Encoding by re-encoding it
+// and comparing the bytes.
+func CheckCanonical(data []byte) error {
+	var generic interface{}
+	if err := decMode.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("invalid cbor: %v", err)
+	}
+
+	reencoded, err := encMode.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode cbor: %v", err)
+	}
+
+	if !bytes.Equal(data, reencoded) {
+		return ErrNonCanonical
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodePayloadRejectsNonCanonical(t *testing.T) {
+	payload := &PayloadV1{
+		Version:  Version1,
+		Provider: "google",
+		JWKSKeys: map[string][]byte{"kid": {1, 2, 3}},
+		DKIMKeys: map[string]map[string][]byte{"gmail.com": {"20230601": {4, 5, 6}}},
+	}
+
+	encoded, err := EncodeV1(payload)
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+
+	decoded, err := DecodeV1(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	assert.Equal(t, payload, decoded)
+
+	tests := map[string][]byte{
+		// {"b": 1, "a": 2}, keys not sorted
+		"unsorted keys": {0xa2, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02},
+		// 1 encoded as a two byte integer
+		"non-shortest integer": {0x19, 0x00, 0x01},
+		// indefinite length byte string
+		"indefinite length": {0x5f, 0x41, 0x01, 0xff},
+		// {"a": 1, "a": 2}
+		"duplicate keys": {0xa2, 0x61, 0x61, 0x01, 0x61, 0x61, 0x02},
+		"trailing bytes": append(append([]byte{}, encoded...), 0x00),
+	}
+
+	for name, input := range tests {
+		var v interface{}
+		assert.Error(t, Unmarshal(input, &v), name)
+	}
+
+	assert.ErrorIs(t, CheckCanonical(tests["unsorted keys"]), ErrNonCanonical)
+	assert.NoError(t, CheckCanonical([]byte{0xa2, 0x61, 0x61, 0x02, 0x61, 0x62, 0x01}))
+}
@@ -0,0 +1,68 @@
+package schema
+
+import "fmt"
+
+// NonceVersion is the current version of the freshness nonce.
+const NonceVersion = 1
+
+// FreshnessNonce goes into the attestation `nonce` field. It binds the attestation to a recent block, so consumers can
+// reject attestations that were generated before that block, and records when the attested keys were fetched.
+type FreshnessNonce struct {
+	Version     uint64 `cbor:"version"`
+	ChainID     uint64 `cbor:"chain_id"`
+	BlockNumber uint64 `cbor:"block_number"`
+	BlockHash   []byte `cbor:"block_hash"`
+	BlockTime   uint64 `cbor:"block_time"` // unix seconds
+	FetchedAt   uint64 `cbor:"fetched_at"` // unix seconds, time of the oldest key fetch in the payload
+
+	// sha256 of the enclave's effective configuration. The DKIMRegistry parses user_data itself and only accepts
+	// keys there, so the nonce carries the hash for attestations of the flattened DKIM payload.
+	ConfigHash []byte `cbor:"config_hash,omitempty"`
+}
+
+func EncodeNonce(nonce *FreshnessNonce) ([]byte, error) {
+	if nonce.Version != NonceVersion {
+		return nil, fmt.Errorf("unsupported nonce version %d", nonce.Version)
+	}
+	if len(nonce.BlockHash) != 32 {
+		return nil, fmt.Errorf("invalid block hash length %d", len(nonce.BlockHash))
+	}
+
+	encoded, err := Marshal(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode nonce: %v", err)
+	}
+	return encoded, nil
+}
+
+func DecodeNonce(data []byte) (*FreshnessNonce, error) {
+	var nonce FreshnessNonce
+	if err := Unmarshal(data, &nonce); err != nil {
+		return nil, fmt.Errorf("failed to decode nonce: %w", err)
+	}
+
+	if nonce.Version != NonceVersion {
+		return nil, fmt.Errorf("unsupported nonce version %d", nonce.Version)
+	}
+	if len(nonce.BlockHash) != 32 {
+		return nil, fmt.Errorf("invalid block hash length %d", len(nonce.BlockHash))
+	}
+	return &nonce, nil
+}
+
+// CallerNonce wraps a nonce chosen by a caller of the control API before it goes into the attestation `nonce` field.
+// DecodeNonce rejects it as an unknown field, so a caller cannot pass its own bytes off as a freshness nonce.
+type CallerNonce struct {
+	Nonce []byte `cbor:"caller_nonce"`
+}
+
+// CallerNonceOverhead is how many bytes EncodeCallerNonce adds to nonces of up to 65535 bytes.
+const CallerNonceOverhead = 17
+
+func EncodeCallerNonce(nonce []byte) ([]byte, error) {
+	encoded, err := Marshal(&CallerNonce{Nonce: nonce})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode caller nonce: %v", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,84 @@
+package schema
+
+import "fmt"
+
+// Payload versions. Version 0 has no version field, it is recognised by its flat domain;selector layout.
+const (
+	Version0 = 0
+	Version1 = 1
+	Version2 = 2
+
+	LatestVersion = Version2
+)
+
+const (
+	DefaultProvider = "google"
+	DefaultIssuer   = "https://accounts.google.com"
+)
+
+// Encode encodes the payload in the requested version, dropping whatever that version cannot represent. This lets the
+// enclave keep serving contracts that only understand an older version.
+func Encode(payload *PayloadV2, version uint64) ([]byte, error) {
+	switch version {
+	case Version0:
+		return EncodeV0(payload.ToV0())
+	case Version1:
+		return EncodeV1(payload.ToV1())
+	case Version2:
+		return EncodeV2(payload)
+	default:
+		return nil, fmt.Errorf("unsupported payload version %d", version)
+	}
+}
+
+// Decode decodes a payload of any known version and migrates it to the latest one. The version it was encoded in is
+// returned alongside.
+func Decode(data []byte) (*PayloadV2, uint64, error) {
+	version, err := DetectVersion(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch version {
+	case Version0:
+		legacy, err := DecodeV0(data)
+		if err != nil {
+			return nil, version, err
+		}
+		v1, err := MigrateV0(legacy)
+		if err != nil {
+			return nil, version, err
+		}
+		return MigrateV1(v1), version, nil
+	case Version1:
+		v1, err := DecodeV1(data)
+		if err != nil {
+			return nil, version, err
+		}
+		return MigrateV1(v1), version, nil
+	case Version2:
+		v2, err := DecodeV2(data)
+		return v2, version, err
+	default:
+		return nil, version, fmt.Errorf("unsupported payload version %d", version)
+	}
+}
+
+// DetectVersion reads the version field of a payload without decoding the rest of it.
+func DetectVersion(data []byte) (uint64, error) {
+	var header map[string]interface{}
+	if err := decMode.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("payload is not a CBOR map: %v", err)
+	}
+
+	rawVersion, ok := header["version"]
+	if !ok {
+		return Version0, nil
+	}
+
+	version, ok := rawVersion.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("payload version is %T, expected an unsigned integer", rawVersion)
+	}
+	return version, nil
+}
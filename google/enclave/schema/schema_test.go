@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPayload() *PayloadV2 {
+	return &PayloadV2{
+		Version:  Version2,
+		Provider: DefaultProvider,
+		Issuer:   DefaultIssuer,
+		JWKS: &JWKSSection{
+			Source:       "https://www.googleapis.com/oauth2/v3/certs",
+			FetchedAt:    1742270400,
+			EvidenceHash: make([]byte, 32),
+			Keys: []JWKSKey{
+				{Kid: "kid-b", Algorithm: "RS256", PublicKey: []byte{1, 2, 3}},
+				{Kid: "kid-a", Algorithm: "RS256", PublicKey: []byte{4, 5, 6}},
+			},
+		},
+		DKIM: &DKIMSection{
+			FetchedAt: 1742270400,
+			Records: []DKIMRecord{
+				{Domain: "gmail.com", Selector: "20230601", Algorithm: "rsa", PublicKey: []byte{7, 8, 9}},
+				{Domain: "gmail.com", Selector: "20161025", Algorithm: "rsa", PublicKey: []byte{10, 11, 12}},
+			},
+		},
+	}
+}
+
+func TestDecodeDispatchesOnVersion(t *testing.T) {
+	payload := testPayload()
+
+	for _, version := range []uint64{Version0, Version1, Version2} {
+		encoded, err := Encode(payload, version)
+		if err != nil {
+			t.Fatalf("Failed to encode version %d: %v", version, err)
+		}
+
+		detected, err := DetectVersion(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, version, detected)
+
+		decoded, decodedVersion, err := Decode(encoded)
+		if err != nil {
+			t.Fatalf("Failed to decode version %d: %v", version, err)
+		}
+		assert.Equal(t, version, decodedVersion)
+		assert.Equal(t, uint64(Version2), decoded.Version)
+		assert.Equal(t, "20161025", decoded.DKIM.Records[0].Selector, "records must come back sorted")
+		assert.Equal(t, []byte{10, 11, 12}, decoded.DKIM.Records[0].PublicKey)
+
+		if version == Version0 {
+			assert.Nil(t, decoded.JWKS, "version 0 carries no JWKS keys")
+		} else {
+			assert.Len(t, decoded.JWKS.Keys, 2)
+			assert.Equal(t, "kid-a", decoded.JWKS.Keys[0].Kid)
+		}
+	}
+
+	decoded, _, err := Decode(mustEncode(t, payload, Version2))
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+}
+
+func TestDecodeRejectsInvalidPayloads(t *testing.T) {
+	_, _, err := Decode(mustEncode(t, map[string]interface{}{"version": uint64(99)}, -1))
+	assert.Error(t, err, "unknown version")
+
+	_, _, err = Decode(mustEncode(t, map[string]string{"no-selector": "AQID"}, -1))
+	assert.Error(t, err, "legacy key without selector")
+
+	unsorted := testPayload()
+	raw, err := Marshal(unsorted)
+	assert.NoError(t, err)
+	_, _, err = Decode(raw)
+	assert.ErrorIs(t, err, ErrNonCanonical, "records must be sorted")
+}
+
+// mustEncode encodes v as a payload of the given version, or as plain canonical CBOR if version is negative.
+func mustEncode(t *testing.T, v interface{}, version int) []byte {
+	var (
+		encoded []byte
+		err     error
+	)
+	if version < 0 {
+		encoded, err = Marshal(v)
+	} else {
+		encoded, err = Encode(v.(*PayloadV2), uint64(version))
+	}
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+	return encoded
+}
+
+func TestFreshnessNonceRoundTrip(t *testing.T) {
+	nonce := &FreshnessNonce{
+		Version:     NonceVersion,
+		ChainID:     31337,
+		BlockNumber: 42,
+		BlockHash:   make([]byte, 32),
+		BlockTime:   1742270400,
+		FetchedAt:   1742270401,
+	}
+
+	encoded, err := EncodeNonce(nonce)
+	if err != nil {
+		t.Fatalf("Failed to encode nonce: %v", err)
+	}
+	assert.LessOrEqual(t, len(encoded), 512, "DKIMOracle rejects nonces over 512 bytes")
+
+	decoded, err := DecodeNonce(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, nonce, decoded)
+
+	nonce.BlockHash = []byte{1}
+	_, err = EncodeNonce(nonce)
+	assert.Error(t, err)
+}
+
+func TestCallerNonceIsNotAFreshnessNonce(t *testing.T) {
+	forged, err := EncodeNonce(&FreshnessNonce{Version: NonceVersion, ChainID: 1, BlockHash: make([]byte, 32)})
+	assert.NoError(t, err)
+
+	wrapped, err := EncodeCallerNonce(forged)
+	assert.NoError(t, err)
+	assert.Len(t, wrapped, len(forged)+CallerNonceOverhead-1, "short nonces take a one byte length")
+	_, err = DecodeNonce(wrapped)
+	assert.Error(t, err)
+
+	wrapped, err = EncodeCallerNonce(make([]byte, 300))
+	assert.NoError(t, err)
+	assert.Len(t, wrapped, 300+CallerNonceOverhead)
+}
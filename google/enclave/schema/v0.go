@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// LegacyDKIMPayload is the unversioned version 0 payload: a flat {"domain;selector": base64 DER} map. It is what the
+// current DKIMRegistry contract parses, so it is still produced for that contract.
+type LegacyDKIMPayload map[string]string
+
+func EncodeV0(payload LegacyDKIMPayload) ([]byte, error) {
+	for key := range payload {
+		if _, _, ok := splitLegacyKey(key); !ok {
+			return nil, fmt.Errorf("invalid legacy DKIM key %q, expected domain;selector", key)
+		}
+	}
+
+	encoded, err := Marshal(map[string]string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode v0 payload: %v", err)
+	}
+	return encoded, nil
+}
+
+func DecodeV0(data []byte) (LegacyDKIMPayload, error) {
+	var payload map[string]string
+	if err := Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode v0 payload: %w", err)
+	}
+
+	for key := range payload {
+		if _, _, ok := splitLegacyKey(key); !ok {
+			return nil, fmt.Errorf("invalid legacy DKIM key %q, expected domain;selector", key)
+		}
+	}
+	return LegacyDKIMPayload(payload), nil
+}
+
+// MigrateV0 lifts a legacy payload into version 1. Version 0 payloads were only ever produced for Google DKIM keys.
+func MigrateV0(payload LegacyDKIMPayload) (*PayloadV1, error) {
+	result := &PayloadV1{
+		Version:  Version1,
+		Provider: DefaultProvider,
+		JWKSKeys: map[string][]byte{},
+		DKIMKeys: map[string]map[string][]byte{},
+	}
+
+	for key, value := range payload {
+		domain, selector, _ := splitLegacyKey(key)
+		derBytes, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode v0 DKIM key %s: %v", key, err)
+		}
+		if result.DKIMKeys[domain] == nil {
+			result.DKIMKeys[domain] = map[string][]byte{}
+		}
+		result.DKIMKeys[domain][selector] = derBytes
+	}
+	return result, nil
+}
+
+func splitLegacyKey(key string) (domain string, selector string, ok bool) {
+	domain, selector, ok = strings.Cut(key, ";")
+	return domain, selector, ok && domain != "" && selector != ""
+}
@@ -0,0 +1,75 @@
+package schema
+
+import "fmt"
+
+// PayloadV1 is the first versioned payload. Keys are DER encoded PKIX public keys.
+type PayloadV1 struct {
+	Version  uint64                       `cbor:"version"`
+	Provider string                       `cbor:"provider"`
+	JWKSKeys map[string][]byte            `cbor:"jwks_keys"` // kid -> DER
+	DKIMKeys map[string]map[string][]byte `cbor:"dkim_keys"` // domain -> selector -> DER
+}
+
+func EncodeV1(payload *PayloadV1) ([]byte, error) {
+	if payload.Version != Version1 {
+		return nil, fmt.Errorf("expected payload version %d, got %d", Version1, payload.Version)
+	}
+
+	encoded, err := Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode v1 payload: %v", err)
+	}
+	return encoded, nil
+}
+
+func DecodeV1(data []byte) (*PayloadV1, error) {
+	var payload PayloadV1
+	if err := Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode v1 payload: %w", err)
+	}
+
+	if payload.Version != Version1 {
+		return nil, fmt.Errorf("expected payload version %d, got %d", Version1, payload.Version)
+	}
+	return &payload, nil
+}
+
+// MigrateV1 lifts a version 1 payload into version 2. Version 1 did not record fetch metadata, so those fields stay
+// empty and the key algorithms are assumed to be the RSA keys the enclave has always fetched.
+func MigrateV1(payload *PayloadV1) *PayloadV2 {
+	result := &PayloadV2{
+		Version:  Version2,
+		Provider: payload.Provider,
+	}
+	if payload.Provider == DefaultProvider {
+		result.Issuer = DefaultIssuer
+	}
+
+	if len(payload.JWKSKeys) > 0 {
+		result.JWKS = &JWKSSection{}
+		for kid, key := range payload.JWKSKeys {
+			result.JWKS.Keys = append(result.JWKS.Keys, JWKSKey{
+				Kid:       kid,
+				Algorithm: "RS256",
+				PublicKey: key,
+			})
+		}
+	}
+
+	if len(payload.DKIMKeys) > 0 {
+		result.DKIM = &DKIMSection{}
+		for domain, selectors := range payload.DKIMKeys {
+			for selector, key := range selectors {
+				result.DKIM.Records = append(result.DKIM.Records, DKIMRecord{
+					Domain:    domain,
+					Selector:  selector,
+					Algorithm: "rsa",
+					PublicKey: key,
+				})
+			}
+		}
+	}
+
+	result.sort()
+	return result
+}
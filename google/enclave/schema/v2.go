@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+)
+
+// PayloadV2 is the current payload. Unlike earlier versions it records where and when keys were fetched together with
+// hashes of the raw responses, so the attested keys can be traced back to the evidence they were parsed from.
+type PayloadV2 struct {
+	Version  uint64       `cbor:"version"`
+	Provider string       `cbor:"provider"`
+	Issuer   string       `cbor:"issuer"`
+	JWKS     *JWKSSection `cbor:"jwks,omitempty"`
+	DKIM     *DKIMSection `cbor:"dkim,omitempty"`
+
+	ConfigHash []byte `cbor:"config_hash,omitempty"` // sha256 of the enclave's effective configuration
+}
+
+type JWKSSection struct {
+	Source       string    `cbor:"source"`        // URL the key set was fetched from
+	FetchedAt    uint64    `cbor:"fetched_at"`    // unix seconds
+	EvidenceHash []byte    `cbor:"evidence_hash"` // sha256 of the raw response body
+	Keys         []JWKSKey `cbor:"keys"`          // sorted by kid
+}
+
+type JWKSKey struct {
+	Kid       string `cbor:"kid"`
+	Algorithm string `cbor:"alg"` // JWS algorithm, e.g. RS256
+	Use       string `cbor:"use,omitempty"`
+	PublicKey []byte `cbor:"public_key"` // DER encoded PKIX public key
+}
+
+type DKIMSection struct {
+	FetchedAt uint64       `cbor:"fetched_at"` // unix seconds
+	Records   []DKIMRecord `cbor:"records"`    // sorted by domain, then selector
+}
+
+type DKIMRecord struct {
+	Domain       string `cbor:"domain"`
+	Selector     string `cbor:"selector"`
+	Algorithm    string `cbor:"alg"`                     // DKIM k= tag, e.g. rsa
+	PublicKey    []byte `cbor:"public_key"`              // DER encoded PKIX public key
+	EvidenceHash []byte `cbor:"evidence_hash,omitempty"` // sha256 of the TXT record
+}
+
+// EncodeV2 sorts the key lists of the payload in place before encoding it.
+func EncodeV2(payload *PayloadV2) ([]byte, error) {
+	if payload.Version != Version2 {
+		return nil, fmt.Errorf("expected payload version %d, got %d", Version2, payload.Version)
+	}
+
+	payload.sort()
+	if err := payload.validate(); err != nil {
+		return nil, err
+	}
+
+	encoded, err := Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode v2 payload: %v", err)
+	}
+	return encoded, nil
+}
+
+func DecodeV2(data []byte) (*PayloadV2, error) {
+	var payload PayloadV2
+	if err := Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode v2 payload: %w", err)
+	}
+
+	if payload.Version != Version2 {
+		return nil, fmt.Errorf("expected payload version %d, got %d", Version2, payload.Version)
+	}
+	// Arrays are not sorted by the canonical encoding itself, an unsorted payload has a second valid encoding
+	if err := payload.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNonCanonical, err)
+	}
+	return &payload, nil
+}
+
+// ToV1 drops everything version 1 cannot represent.
+func (p *PayloadV2) ToV1() *PayloadV1 {
+	result := &PayloadV1{
+		Version:  Version1,
+		Provider: p.Provider,
+		JWKSKeys: map[string][]byte{},
+		DKIMKeys: map[string]map[string][]byte{},
+	}
+
+	if p.JWKS != nil {
+		for _, key := range p.JWKS.Keys {
+			result.JWKSKeys[key.Kid] = key.PublicKey
+		}
+	}
+
+	if p.DKIM != nil {
+		for _, record := range p.DKIM.Records {
+			if result.DKIMKeys[record.Domain] == nil {
+				result.DKIMKeys[record.Domain] = map[string][]byte{}
+			}
+			result.DKIMKeys[record.Domain][record.Selector] = record.PublicKey
+		}
+	}
+	return result
+}
+
+// ToV0 keeps only the DKIM keys, in the flattened layout the DKIMRegistry contract parses.
+func (p *PayloadV2) ToV0() LegacyDKIMPayload {
+	result := LegacyDKIMPayload{}
+	if p.DKIM != nil {
+		for _, record := range p.DKIM.Records {
+			result[record.Domain+";"+record.Selector] = base64.StdEncoding.EncodeToString(record.PublicKey)
+		}
+	}
+	return result
+}
+
+func (p *PayloadV2) sort() {
+	if p.JWKS != nil {
+		sort.Slice(p.JWKS.Keys, func(i, j int) bool {
+			return p.JWKS.Keys[i].Kid < p.JWKS.Keys[j].Kid
+		})
+	}
+	if p.DKIM != nil {
+		sort.Slice(p.DKIM.Records, func(i, j int) bool {
+			return dkimRecordLess(&p.DKIM.Records[i], &p.DKIM.Records[j])
+		})
+	}
+}
+
+func (p *PayloadV2) validate() error {
+	if p.JWKS != nil {
+		for i, key := range p.JWKS.Keys {
+			if key.Kid == "" || len(key.PublicKey) == 0 {
+				return fmt.Errorf("incomplete JWKS key at index %d", i)
+			}
+			if i > 0 && p.JWKS.Keys[i-1].Kid >= key.Kid {
+				return fmt.Errorf("JWKS keys not sorted by unique kid at index %d", i)
+			}
+		}
+	}
+
+	if p.DKIM != nil {
+		for i, record := range p.DKIM.Records {
+			if record.Domain == "" || record.Selector == "" || len(record.PublicKey) == 0 {
+				return fmt.Errorf("incomplete DKIM record at index %d", i)
+			}
+			if i > 0 && !dkimRecordLess(&p.DKIM.Records[i-1], &record) {
+				return fmt.Errorf("DKIM records not sorted by unique domain and selector at index %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+func dkimRecordLess(a, b *DKIMRecord) bool {
+	if a.Domain != b.Domain {
+		return a.Domain < b.Domain
+	}
+	return a.Selector < b.Selector
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ShutdownTimeout is how long requests in flight when the server is stopped may take to answer.
+var ShutdownTimeout = 10 * time.Second
+
+// API publishes the latest attestation of the enclave to off-chain verifiers. It reads the attestation from the
+// enclave control API and renders every response once per attestation, so repeated requests are answered from memory
+// and revalidated with their ETag.
+type API struct {
+	control      *control.Client
+	pollInterval time.Duration
+
+	mu       sync.RWMutex
+	snapshot *snapshot
+}
+
+func New(controlClient *control.Client, pollInterval time.Duration) *API {
+	return &API{control: controlClient, pollInterval: pollInterval}
+}
+
+// Register adds the API routes to mux.
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/", a.handleGet)
+}
+
+// Run reads the latest attestation from the enclave every poll interval until ctx is done.
+func (a *API) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *API) poll(ctx context.Context) {
+	pollCtx, cancel := context.WithTimeout(ctx, a.pollInterval)
+	defer cancel()
+
+	attestation, err := a.control.LatestAttestation(pollCtx)
+	if err != nil {
+		if strings.Contains(err.Error(), control.ErrNoAttestation.Error()) {
+			log.Infof("Enclave has not generated an attestation yet")
+		} else {
+			log.Warnf("Failed to read latest attestation from enclave: %v", err)
+		}
+		return
+	}
+
+	a.mu.RLock()
+	current := a.snapshot
+	a.mu.RUnlock()
+	if current != nil && current.attestationHash == crypto.Keccak256Hash(attestation.Document) {
+		return
+	}
+
+	next, err := newSnapshot(attestation)
+	if err != nil {
+		log.Errorf("Failed to publish latest attestation: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	a.snapshot = next
+	a.mu.Unlock()
+	log.Infof("Publishing attestation %s", next.attestationHash.Hex())
+}
+
+func (a *API) handleGet(w http.ResponseWriter, req *http.Request) {
+	a.mu.RLock()
+	snapshot := a.snapshot
+	a.mu.RUnlock()
+
+	if snapshot == nil {
+		http.Error(w, "no attestation published yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	res, ok := snapshot.resources[req.URL.Path]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("ETag", res.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(req.Header.Get("If-None-Match"), res.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", res.contentType)
+	if _, err := w.Write(res.body); err != nil {
+		log.Debugf("Failed to write %s: %v", req.URL.Path, err)
+	}
+}
+
+// etagMatches reports whether the If-None-Match header lists etag. Weak tags compare equal to strong ones.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Serve serves handler on addr until ctx is done.
+func Serve(ctx context.Context, addr string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", addr, err)
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		stopped <- server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("HTTP server listening on %s", listener.Addr())
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return fmt.Errorf("HTTP server stopped: %v", err)
+	}
+
+	if err := <-stopped; err != nil {
+		server.Close()
+		return fmt.Errorf("HTTP server did not finish its requests in time: %w", err)
+	}
+	log.Infof("HTTP server on %s stopped", addr)
+	return nil
+}
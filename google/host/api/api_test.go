@@ -0,0 +1,204 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testAPI serves a snapshot of an attestation of keys.
+func testAPI(t *testing.T, keys schema.LegacyDKIMPayload) (*API, *control.Attestation) {
+	userData, err := schema.EncodeV0(keys)
+	if err != nil {
+		t.Fatalf("Failed to encode user data: %v", err)
+	}
+	attestation := &control.Attestation{
+		Document:  []byte("document"),
+		UserData:  userData,
+		CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	snapshot, err := newSnapshot(attestation)
+	if err != nil {
+		t.Fatalf("Failed to render snapshot: %v", err)
+	}
+	return &API{snapshot: snapshot}, attestation
+}
+
+func rsaKey(t *testing.T) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal RSA key: %v", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+func get(a *API, path string, ifNoneMatch string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	a.Register(mux)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestETag(t *testing.T) {
+	_, key := rsaKey(t)
+	a, _ := testAPI(t, schema.LegacyDKIMPayload{"gmail.com;20230601": key})
+
+	first := get(a, "/v1/keys", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("Expected a body with an ETag, got %d %q", first.Code, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"matching tag", etag, http.StatusNotModified},
+		{"weak tag", "W/" + etag, http.StatusNotModified},
+		{"any tag", "*", http.StatusNotModified},
+		{"one of several", `"other", ` + etag, http.StatusNotModified},
+		{"other tag", `"other"`, http.StatusOK},
+		{"unquoted tag", etag[1 : len(etag)-1], http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := get(a, "/v1/keys", test.ifNoneMatch)
+			if rec.Code != test.status {
+				t.Fatalf("Status %d, want %d", rec.Code, test.status)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("ETag %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+			if test.status == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("A 304 must not have a body, got %q", rec.Body.String())
+			}
+		})
+	}
+
+	// Every resource has its own tag
+	if other := get(a, "/v1/jwks.json", "").Header().Get("ETag"); other == etag {
+		t.Errorf("Different resources share the ETag %s", etag)
+	}
+	if rec := get(a, "/v1/jwks.json", etag); rec.Code != http.StatusOK {
+		t.Errorf("The tag of another resource matched, status %d", rec.Code)
+	}
+}
+
+func TestNotPublished(t *testing.T) {
+	if rec := get(&API{}, "/v1/keys", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status %d before the first attestation, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	_, key := rsaKey(t)
+	a, _ := testAPI(t, schema.LegacyDKIMPayload{"gmail.com;20230601": key})
+	if rec := get(a, "/v1/keys/gmail.com/other", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Status %d for a key that is not attested, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestKeyInclusion(t *testing.T) {
+	_, key := rsaKey(t)
+	a, attestation := testAPI(t, schema.LegacyDKIMPayload{"gmail.com;20230601": key, "gmail.com;20161025": key})
+
+	rec := get(a, "/v1/keys/gmail.com/20230601", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var inclusion KeyInclusion
+	if err := json.Unmarshal(rec.Body.Bytes(), &inclusion); err != nil {
+		t.Fatalf("Failed to parse inclusion data: %v", err)
+	}
+
+	der, _ := base64.StdEncoding.DecodeString(key)
+	keyHash := sha256.Sum256(der)
+	if inclusion.Domain != "gmail.com" || inclusion.Selector != "20230601" || inclusion.Entry != "gmail.com;20230601" {
+		t.Errorf("Unexpected entry %+v", inclusion)
+	}
+	if inclusion.Key != key || string(inclusion.KeyHash) != string(keyHash[:]) {
+		t.Errorf("Unexpected key %s with hash %x", inclusion.Key, inclusion.KeyHash)
+	}
+	if string(inclusion.UserData) != string(attestation.UserData) {
+		t.Errorf("User data %x, want %x", inclusion.UserData, attestation.UserData)
+	}
+	if inclusion.AttestationHash != crypto.Keccak256Hash(attestation.Document) {
+		t.Errorf("Attestation hash %s", inclusion.AttestationHash.Hex())
+	}
+
+	// The user data holds the key under the entry
+	attested, err := schema.DecodeV0(inclusion.UserData)
+	if err != nil || attested[inclusion.Entry] != inclusion.Key {
+		t.Errorf("Key not in the user data: %v", err)
+	}
+
+	var all []KeyInclusion
+	if err := json.Unmarshal(get(a, "/v1/keys", "").Body.Bytes(), &all); err != nil {
+		t.Fatalf("Failed to parse key list: %v", err)
+	}
+	if len(all) != 2 || all[0].Entry != "gmail.com;20161025" || all[1].Entry != "gmail.com;20230601" {
+		t.Errorf("Expected both keys sorted by entry, got %+v", all)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	private, key := rsaKey(t)
+	ed25519Key := base64.StdEncoding.EncodeToString(make([]byte, 32)) // k=ed25519 DKIM keys are raw 32 byte keys
+	a, _ := testAPI(t, schema.LegacyDKIMPayload{
+		"gmail.com;20230601": key,
+		"gmail.com;ed":       ed25519Key,
+		"gmail.com;broken":   "not base64!",
+	})
+
+	var jwks JWKS
+	if err := json.Unmarshal(get(a, "/v1/jwks.json", "").Body.Bytes(), &jwks); err != nil {
+		t.Fatalf("Failed to parse JWKS: %v", err)
+	}
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("Expected only the RSA key in the JWKS, got %+v", jwks.Keys)
+	}
+
+	jwk := jwks.Keys[0]
+	if jwk.Kty != "RSA" || jwk.Kid != "gmail.com;20230601" || jwk.Alg != "RS256" || jwk.Use != "sig" {
+		t.Errorf("Unexpected JWK %+v", jwk)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || new(big.Int).SetBytes(n).Cmp(private.N) != 0 {
+		t.Errorf("Modulus %s does not decode to the key's: %v", jwk.N, err)
+	}
+	if jwk.E != "AQAB" {
+		t.Errorf("Exponent %s, want AQAB (65537)", jwk.E)
+	}
+
+	// The keys left out of the JWKS are still published
+	for path, hashed := range map[string]bool{"/v1/keys/gmail.com/ed": true, "/v1/keys/gmail.com/broken": false} {
+		var inclusion KeyInclusion
+		if err := json.Unmarshal(get(a, path, "").Body.Bytes(), &inclusion); err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		if (len(inclusion.KeyHash) > 0) != hashed {
+			t.Errorf("%s has key hash %x", path, inclusion.KeyHash)
+		}
+	}
+}
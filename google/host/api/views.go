@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/schema"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AttestationInfo describes the latest attestation document, which is served as is at /v1/attestation.
+type AttestationInfo struct {
+	AttestationHash common.Hash   `json:"attestation_hash"` // keccak256 of the document, as referenced on chain
+	Document        hexutil.Bytes `json:"document"`
+	UserData        hexutil.Bytes `json:"user_data"`
+	Nonce           hexutil.Bytes `json:"nonce,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+// KeyInclusion is what a verifier needs to check that a key is attested: the document's user_data is the CBOR map
+// UserData, which holds Key under Entry.
+type KeyInclusion struct {
+	Domain          string        `json:"domain"`
+	Selector        string        `json:"selector"`
+	Entry           string        `json:"entry"`    // domain;selector
+	Key             string        `json:"key"`                // base64 DER PKIX public key
+	KeyHash         hexutil.Bytes `json:"key_hash,omitempty"` // sha256 of the DER key, absent if Key is not base64
+	UserData        hexutil.Bytes `json:"user_data"`
+	AttestationHash common.Hash   `json:"attestation_hash"`
+}
+
+// JWK is an RSA key in the JSON Web Key format. The kid is the DKIM entry name, domain;selector.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// resource is a rendered response with its entity tag.
+type resource struct {
+	contentType string
+	body        []byte
+	etag        string
+}
+
+func newResource(contentType string, body []byte) *resource {
+	hash := sha256.Sum256(body)
+	return &resource{contentType: contentType, body: body, etag: fmt.Sprintf("%q", hex.EncodeToString(hash[:16]))}
+}
+
+func jsonResource(v interface{}) (*resource, error) {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return newResource("application/json", append(body, '\n')), nil
+}
+
+// snapshot holds every response for one attestation, by path. The keys are taken from the attested user_data, not from
+// the payload the enclave reports alongside it, so only keys a verifier would find in the document are published. Keys
+// that are not base64 DER RSA keys, such as ed25519 DKIM keys, are left out of the JWKS but published like the others.
+type snapshot struct {
+	attestationHash common.Hash
+	resources       map[string]*resource
+}
+
+func newSnapshot(attestation *control.Attestation) (*snapshot, error) {
+	attested, err := schema.DecodeV0(attestation.UserData)
+	if err != nil {
+		return nil, fmt.Errorf("attested user data is not a DKIM key map: %v", err)
+	}
+
+	attestationHash := crypto.Keccak256Hash(attestation.Document)
+	info := &AttestationInfo{
+		AttestationHash: attestationHash,
+		Document:        attestation.Document,
+		UserData:        attestation.UserData,
+		Nonce:           attestation.Nonce,
+		CreatedAt:       attestation.CreatedAt,
+	}
+
+	entries := make([]string, 0, len(attested))
+	for entry := range attested {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	s := &snapshot{
+		attestationHash: attestationHash,
+		resources: map[string]*resource{
+			"/v1/attestation": newResource("application/cbor", attestation.Document),
+		},
+	}
+	views := map[string]interface{}{
+		"/v1/attestation.json": info,
+		"/v1/payload":          attestation.Payload,
+	}
+
+	keys := []*KeyInclusion{}
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, entry := range entries {
+		domain, selector, _ := strings.Cut(entry, ";")
+		key := attested[entry]
+		inclusion := &KeyInclusion{
+			Domain:          domain,
+			Selector:        selector,
+			Entry:           entry,
+			Key:             key,
+			UserData:        attestation.UserData,
+			AttestationHash: attestationHash,
+		}
+		keys = append(keys, inclusion)
+		views["/v1/keys/"+domain+"/"+selector] = inclusion
+
+		derBytes, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			log.Warnf("Leaving attested key %s out of the JWKS, it is not base64: %v", entry, err)
+			continue
+		}
+		keyHash := sha256.Sum256(derBytes)
+		inclusion.KeyHash = keyHash[:]
+
+		jwk, err := rsaJWK(entry, derBytes)
+		if err != nil {
+			log.Warnf("Leaving attested key %s out of the JWKS: %v", entry, err)
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+	views["/v1/keys"] = keys
+	views["/v1/jwks.json"] = jwks
+
+	for path, view := range views {
+		s.resources[path], err = jsonResource(view)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+func rsaJWK(kid string, derBytes []byte) (*JWK, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(derBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DER public key: %v", err)
+	}
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key")
+	}
+
+	return &JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}, nil
+}
@@ -0,0 +1,192 @@
+package auditlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	recordsStored = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_records_stored_total",
+		Help: "Enclave audit records appended to the audit log.",
+	})
+	recordsLost = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_records_lost_total",
+		Help: "Enclave audit records dropped by the enclave before they were collected.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(recordsStored, recordsLost)
+}
+
+// Store appends the audit records the enclave signs to a file as JSON lines, one record per line, in the order the
+// enclave produced them. Records the enclave dropped before they were collected are replaced by a gap marker line,
+// {"gap": audit.Gap}. The file is what the auditverify command checks.
+type Store struct {
+	file    *os.File
+	session string // of the last stored record
+	next    uint64 // sequence expected next in session
+}
+
+// Open opens the audit log at path, creating it if needed, and resumes after its last record.
+func Open(path string) (*Store, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	store := &Store{file: file}
+	err = Read(file, func(record *audit.Record) error {
+		store.session = record.Session
+		store.next = record.Sequence + 1
+		return nil
+	}, func(gap *audit.Gap) error {
+		store.session = gap.Session
+		store.next = gap.To + 1
+		return nil
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *Store) Close() error {
+	return s.file.Close()
+}
+
+// gapLine is how a gap is stored. Records have no gap field, so the two cannot be mistaken for each other.
+type gapLine struct {
+	Gap *audit.Gap `json:"gap"`
+}
+
+// Read calls onRecord with every record and onGap with every gap marker of an audit log, in order.
+func Read(r io.Reader, onRecord func(*audit.Record) error, onGap func(*audit.Gap) error) error {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 4096), 1<<20)
+	for line := 1; lines.Scan(); line++ {
+		var marker gapLine
+		if err := json.Unmarshal(lines.Bytes(), &marker); err != nil {
+			return fmt.Errorf("invalid audit record on line %d: %v", line, err)
+		}
+		if marker.Gap != nil {
+			if err := onGap(marker.Gap); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+
+		var record audit.Record
+		if err := json.Unmarshal(lines.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid audit record on line %d: %v", line, err)
+		}
+		if err := onRecord(&record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return nil
+}
+
+// Run stores new records read from the enclave control API every poll interval until ctx is done.
+func (s *Store) Run(ctx context.Context, controlClient *control.Client, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.poll(ctx, controlClient, pollInterval); err != nil && ctx.Err() == nil {
+			log.Warnf("Failed to collect enclave audit records: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll stores every record the enclave has after the last stored one.
+func (s *Store) poll(ctx context.Context, controlClient *control.Client, pollInterval time.Duration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, pollInterval)
+	defer cancel()
+
+	for {
+		batch, err := controlClient.AuditRecords(pollCtx, s.session, s.next)
+		if err != nil {
+			return err
+		}
+		if err := s.append(batch); err != nil {
+			return err
+		}
+		if len(batch.Records) < control.MaxAuditRecords {
+			return nil
+		}
+	}
+}
+
+func (s *Store) append(batch *control.AuditBatch) error {
+	if len(batch.Records) == 0 {
+		return nil
+	}
+
+	first := batch.Records[0]
+	if batch.Session != s.session {
+		log.Infof("Collecting audit session %s", batch.Session)
+		s.session, s.next = batch.Session, 0
+	}
+	for _, record := range batch.Records {
+		if record.Session != batch.Session {
+			return errors.New("enclave returned a record of another session")
+		}
+	}
+
+	// The enclave only keeps a bounded number of records, the marker lets the verifier go on after the gap
+	if first.Sequence > s.next {
+		log.Errorf("Audit records %d to %d of session %s were lost before they were collected", s.next, first.Sequence-1, s.session)
+		gap := &audit.Gap{Session: s.session, From: s.next, To: first.Sequence - 1}
+		if err := s.write(&gapLine{Gap: gap}); err != nil {
+			return fmt.Errorf("failed to write audit gap: %v", err)
+		}
+		s.next = first.Sequence
+		recordsLost.Add(float64(gap.To - gap.From + 1))
+	}
+
+	for _, record := range batch.Records {
+		if err := s.write(record); err != nil {
+			return fmt.Errorf("failed to write audit record: %v", err)
+		}
+		s.next = record.Sequence + 1
+		recordsStored.Inc()
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %v", err)
+	}
+	log.Infof("Stored %d audit records of session %s", len(batch.Records), s.session)
+	return nil
+}
+
+// write appends v to the log as one JSON line.
+func (s *Store) write(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
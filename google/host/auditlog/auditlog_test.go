@@ -0,0 +1,82 @@
+package auditlog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+)
+
+func newLog(t *testing.T, size int) *audit.Log {
+	auditLog, err := audit.NewLog(size)
+	if err != nil {
+		t.Fatalf("Failed to start audit log: %v", err)
+	}
+	return auditLog
+}
+
+func appendRecords(t *testing.T, auditLog *audit.Log, n int) {
+	for i := 0; i < n; i++ {
+		if err := auditLog.Append(&audit.Record{PayloadHash: []byte{byte(i)}}); err != nil {
+			t.Fatalf("Failed to append audit record: %v", err)
+		}
+	}
+}
+
+// collect stores what the enclave would return to the store's next poll.
+func collect(t *testing.T, store *Store, auditLog *audit.Log) {
+	batch := &control.AuditBatch{Session: auditLog.Session(), Records: auditLog.Since(store.session, store.next)}
+	if err := store.append(batch); err != nil {
+		t.Fatalf("Failed to store audit records: %v", err)
+	}
+}
+
+func TestStoreMarksLostRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit-log.jsonl")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+
+	// Record 2 is pushed out of the enclave buffer before the host collects it
+	first := newLog(t, 3)
+	appendRecords(t, first, 2)
+	collect(t, store, first)
+	appendRecords(t, first, 4)
+	collect(t, store, first)
+
+	// The next session is collected in full, after the store restarted
+	store.Close()
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen audit log: %v", err)
+	}
+	if store.session != first.Session() || store.next != 6 {
+		t.Errorf("Reopened store resumes at %s/%d, want %s/6", store.session, store.next, first.Session())
+	}
+	second := newLog(t, 3)
+	appendRecords(t, second, 2)
+	collect(t, store, second)
+	store.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	verifier := audit.NewVerifier()
+	if err := Read(file, verifier.Add, verifier.Gap); err != nil {
+		t.Fatalf("Audit log does not verify: %v", err)
+	}
+
+	report := verifier.Report()
+	if report.Records != 7 || report.Sessions != 2 || len(report.Signers) != 2 {
+		t.Errorf("Verified %d records in %d sessions with %d signers, want 7 in 2 with 2", report.Records, report.Sessions, len(report.Signers))
+	}
+	if want := []audit.Gap{{Session: first.Session(), From: 2, To: 2}}; !reflect.DeepEqual(report.Gaps, want) {
+		t.Errorf("Gaps %+v, want %+v", report.Gaps, want)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/audit"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/auditlog"
+)
+
+// Exit status codes
+const (
+	exitOK      = 0 // every record verified
+	exitInvalid = 1 // a record is tampered with, missing, out of order or not signed by the attested session key
+	exitUsage   = 2 // the audit log could not be read
+	exitGaps    = 3 // every stored record verified, but the enclave dropped records before the host collected them
+)
+
+// auditverify checks an audit log written by the host: every session must start with an attestation of its signing
+// key, and every record must be signed by that key and link to the record before it in its session, or follow a gap
+// marker.
+func main() {
+	path := flag.String("file", config.DefaultAuditPath, "audit log to verify")
+	flag.Parse()
+
+	file, err := os.Open(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening audit log: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	defer file.Close()
+
+	verifier := audit.NewVerifier()
+	if err := auditlog.Read(file, verifier.Add, verifier.Gap); err != nil {
+		fmt.Fprintf(os.Stderr, "Audit log %s is invalid: %v\n", *path, err)
+		os.Exit(exitInvalid)
+	}
+
+	report := verifier.Report()
+	fmt.Printf("Verified %d records in %d sessions\n", report.Records, report.Sessions)
+	for _, signer := range report.Signers {
+		fmt.Printf("Signed by attested key %s\n", signer.Hex())
+	}
+	for _, gap := range report.Gaps {
+		fmt.Printf("Records %d to %d of session %s were lost\n", gap.From, gap.To, gap.Session)
+	}
+	for _, session := range report.Unattested {
+		fmt.Printf("Session %s lost its key attestation, its records are only checked against each other\n", session)
+	}
+	if len(report.Gaps) > 0 {
+		os.Exit(exitGaps)
+	}
+	os.Exit(exitOK)
+}
@@ -2,21 +2,253 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 
+	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/tracing"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/api"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/auditlog"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/health"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/logcollector"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/metrics"
 	"github.com/EkamSinghPandher/Tee-Google/google/host/proxy"
+	"github.com/EkamSinghPandher/Tee-Google/google/host/relayer"
+	vsockproxy "github.com/EkamSinghPandher/Tee-Google/vsock/proxy"
 	log "github.com/sirupsen/logrus"
 )
 
+// Exit status codes
+const (
+	exitOK           = 0 // stopped by a signal, everything drained
+	exitConfig       = 1 // invalid configuration
+	exitFailed       = 2 // a service failed
+	exitDrainTimeout = 3 // stopped by a signal, but connections or submissions had to be cut
+)
+
 func main() {
-	ctx := context.Background()
+	os.Exit(run())
+}
+
+func run() int {
 	log.Info("Starting google auth POC host service")
 
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Errorf("Error loading config: %v", err)
+		return exitConfig
+	}
+	vsockproxy.DrainTimeout = cfg.ShutdownTimeout.Duration
+	relayer.ShutdownTimeout = cfg.ShutdownTimeout.Duration
+	api.ShutdownTimeout = cfg.ShutdownTimeout.Duration
+
+	chainProfiles, err := client.ProfilesFromConfig(cfg.Chains)
+	if err != nil {
+		log.Errorf("Error loading chain profiles: %v", err)
+		return exitConfig
+	}
+
+	// Proxy connection spans join the traces the enclave starts
+	if cfg.Tracing.Endpoint != "" {
+		shutdownTracing, err := tracing.Init(context.Background(), "tee-google-host", cfg.Tracing.Endpoint, nil, cfg.Tracing.SampleRatio)
+		if err != nil {
+			log.Errorf("Error initializing tracing: %v", err)
+			return exitConfig
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Warnf("Error flushing traces: %v", err)
+			}
+		}()
+	}
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// A failing service stops the others too
+	ctx, cancel := context.WithCancel(signalCtx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*len(chainProfiles)+5)
+	start := func(name string, service func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service(ctx); err != nil {
+				errs <- fmt.Errorf("%s: %w", name, err)
+				cancel()
+			}
+		}()
+	}
+
+	// Readiness of the proxies below, their upstreams and the enclave, served on its own address whether or not the
+	// API is enabled
+	checker := health.New(cfg.Health)
+
+	// The enclave has no console, its logs are streamed here
+	if cfg.Logs.VsockPort != 0 {
+		start("log collector", func(ctx context.Context) error {
+			return logcollector.InitVsockLogCollector(ctx, cfg.Logs.VsockPort, cfg.Logs.Output)
+		})
+	}
+
 	// Existing Google API proxy
-	go proxy.InitVsockToTcpProxy(ctx, 50001, 443, "https://www.googleapis.com")
+	start("google proxy", func(ctx context.Context) error {
+		return proxy.InitVsockToTcpProxy(ctx, cfg.Google.VsockPort, cfg.Google.Port, "https://"+cfg.Google.Host)
+	})
+	checker.AddProxy("google", cfg.Google.VsockPort)
+	checker.AddUpstream("google", net.JoinHostPort(cfg.Google.Host, strconv.FormatUint(uint64(cfg.Google.Port), 10)))
+
+	// The enclave exports its spans to the collector through this proxy
+	if cfg.Tracing.Endpoint != "" {
+		collectorHost, collectorPort, err := net.SplitHostPort(cfg.Tracing.Endpoint)
+		port, portErr := strconv.ParseUint(collectorPort, 10, 32)
+		if err != nil || portErr != nil {
+			log.Errorf("Error parsing tracing endpoint %s", cfg.Tracing.Endpoint)
+			cancel()
+			wg.Wait()
+			return exitConfig
+		}
+		start("tracing proxy", func(ctx context.Context) error {
+			return proxy.InitVsockToTcpProxy(ctx, cfg.Tracing.VsockPort, uint32(port), "http://"+collectorHost)
+		})
+		checker.AddProxy("tracing", cfg.Tracing.VsockPort)
+		checker.AddUpstream("tracing", cfg.Tracing.Endpoint)
+	}
 
-	// New Ethereum RPC proxy - forward vsock port 50002 to anvil at localhost:8545
-	go proxy.InitVsockToTcpProxy(ctx, 50003, 8545, "http://127.0.0.1")
+	for _, chainProfile := range chainProfiles {
+		// Ethereum RPC proxy, one vsock port per chain
+		forwardUrl, tcpPort, err := rpcForward(chainProfile.RPCURL)
+		if err != nil {
+			log.Errorf("Error parsing RPC URL of chain profile %s: %v", chainProfile.Name, err)
+			cancel()
+			wg.Wait()
+			return exitConfig
+		}
+		start(chainProfile.Name+" rpc proxy", func(ctx context.Context) error {
+			return proxy.InitVsockToTcpProxy(ctx, chainProfile.RPCVsockPort, tcpPort, forwardUrl)
+		})
+		checker.AddProxy(chainProfile.Name+" rpc", chainProfile.RPCVsockPort)
+		rpcURL, _ := url.Parse(chainProfile.RPCURL) // parsed by rpcForward already
+		checker.AddUpstream(chainProfile.Name+" rpc", net.JoinHostPort(rpcURL.Hostname(), strconv.FormatUint(uint64(tcpPort), 10)))
 
-	for {
+		// Relayer holding the funded submission key, the enclave only hands it attestations and signed updates
+		if chainProfile.Signer.RelayerPort == 0 {
+			log.Warnf("No relayer port set for chain profile %s, relayer disabled", chainProfile.Name)
+			continue
+		}
+		start(chainProfile.Name+" relayer", func(ctx context.Context) error {
+			return relayer.InitVsockRelayer(ctx, chainProfile.Signer.RelayerPort, chainProfile)
+		})
 	}
+
+	// The public API and the audit log read the enclave over its control API
+	controlClient, err := control.Dial(ctx, cfg.Control.EnclaveCID, cfg.Control.VsockPort)
+	if err != nil {
+		log.Errorf("Error creating enclave control client: %v", err)
+		cancel()
+		wg.Wait()
+		return exitConfig
+	}
+	defer controlClient.Close()
+
+	// Signed records of every enclave refresh cycle, kept for the auditverify command
+	if cfg.Audit.Path != "" {
+		auditStore, err := auditlog.Open(cfg.Audit.Path)
+		if err != nil {
+			log.Errorf("Error opening audit log: %v", err)
+			cancel()
+			wg.Wait()
+			return exitConfig
+		}
+		defer auditStore.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			auditStore.Run(ctx, controlClient, cfg.Audit.PollInterval.Duration)
+		}()
+	} else {
+		log.Warnf("No audit log path set, enclave audit records are not stored")
+	}
+
+	healthMux := http.NewServeMux()
+	checker.Register(healthMux)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		checker.Run(ctx, controlClient)
+	}()
+	start("health checks", func(ctx context.Context) error {
+		return api.Serve(ctx, cfg.Health.ListenAddr, healthMux)
+	})
+
+	// Public HTTP API republishing the enclave's latest attestation
+	if cfg.API.ListenAddr != "" {
+		publicAPI := api.New(controlClient, cfg.API.PollInterval.Duration)
+		mux := http.NewServeMux()
+		publicAPI.Register(mux)
+		// Proxy metrics of the host together with the pipeline metrics of the enclave, read over vsock
+		mux.Handle("GET /metrics", metrics.Handler(cfg.Control.EnclaveCID, cfg.Control.VsockPort))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			publicAPI.Run(ctx)
+		}()
+		start("http api", func(ctx context.Context) error {
+			return api.Serve(ctx, cfg.API.ListenAddr, mux)
+		})
+	} else {
+		log.Warnf("No API listen address set, HTTP API disabled")
+	}
+
+	<-ctx.Done()
+	log.Info("Stopping google auth POC host service")
+	wg.Wait()
+	close(errs)
+
+	code := exitOK
+	for err := range errs {
+		log.Errorf("Error stopping %v", err)
+		if code != exitFailed && signalCtx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
+			code = exitDrainTimeout
+		} else {
+			code = exitFailed
+		}
+	}
+	return code
+}
+
+// rpcForward splits an RPC URL into the scheme and host the proxy forwards to and its TCP port.
+func rpcForward(rpcURL string) (string, uint32, error) {
+	parsed, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", 0, err
+	}
+
+	port := uint64(80)
+	if parsed.Scheme == "https" {
+		port = 443
+	}
+	if parsed.Port() != "" {
+		port, err = strconv.ParseUint(parsed.Port(), 10, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid port in %s: %v", rpcURL, err)
+		}
+	}
+
+	return parsed.Scheme + "://" + parsed.Hostname(), uint32(port), nil
 }
@@ -0,0 +1,260 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	vsockproxy "github.com/EkamSinghPandher/Tee-Google/vsock/proxy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Check is the outcome of one check in a health response.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the body of /healthz and /readyz. Status is ok or failing.
+type Report struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// proxy is a vsock proxy of the host, by the vsock port it listens on.
+type proxy struct {
+	name      string
+	vsockPort uint32
+}
+
+// upstream is a TCP endpoint the host proxies forward to, with the outcome of its last probe.
+type upstream struct {
+	name     string
+	address  string
+	probed   bool
+	err      error
+	probedAt time.Time
+}
+
+// Checker tells whether the host can serve the enclave. Liveness only covers the proxy listeners of the host itself,
+// readiness also needs the upstreams to accept connections and the enclave to answer and keep publishing attested
+// keys. The upstreams and the enclave are probed in the background by Run, so requests never wait on them.
+type Checker struct {
+	cfg       config.HealthConfig
+	listening func(vsockPort uint32) bool
+
+	mu        sync.RWMutex
+	proxies   []proxy
+	upstreams []*upstream
+	answered  time.Time       // last time the enclave answered over the control API
+	status    *control.Status // its last answer
+	statusErr error
+}
+
+func New(cfg config.HealthConfig) *Checker {
+	return &Checker{cfg: cfg, listening: vsockproxy.VsockListening}
+}
+
+// AddProxy makes the vsock proxy listening on vsockPort part of both checks.
+func (c *Checker) AddProxy(name string, vsockPort uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxies = append(c.proxies, proxy{name: name, vsockPort: vsockPort})
+}
+
+// AddUpstream makes address (host:port) part of the readiness check, as reached by a TCP connection.
+func (c *Checker) AddUpstream(name string, address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upstreams = append(c.upstreams, &upstream{name: name, address: address})
+}
+
+// Register adds /healthz and /readyz to mux.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, c.Live())
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, c.Ready(time.Now()))
+	})
+}
+
+// Run probes the upstreams, and the enclave over controlClient, every probe interval until ctx is done.
+func (c *Checker) Run(ctx context.Context, controlClient *control.Client) {
+	ticker := time.NewTicker(c.cfg.ProbeInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		c.probe(ctx, controlClient)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) probe(ctx context.Context, controlClient *control.Client) {
+	c.mu.RLock()
+	upstreams := c.upstreams
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, target := range upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.dial(ctx, target.address)
+			if err != nil && ctx.Err() == nil {
+				log.Warnf("Upstream %s at %s is unreachable: %v", target.name, target.address, err)
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			target.probed, target.err, target.probedAt = true, err, time.Now()
+		}()
+	}
+
+	statusCtx, cancel := context.WithTimeout(ctx, c.cfg.ProbeTimeout.Duration)
+	defer cancel()
+	status, err := controlClient.Status(statusCtx)
+	if err != nil && ctx.Err() == nil {
+		log.Warnf("Enclave did not answer over the control API: %v", err)
+	}
+
+	c.mu.Lock()
+	c.statusErr = err
+	if err == nil {
+		c.answered, c.status = time.Now(), status
+	}
+	c.mu.Unlock()
+	wg.Wait()
+}
+
+// dial opens a TCP connection to address and closes it right away.
+func (c *Checker) dial(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: c.cfg.ProbeTimeout.Duration}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Live reports whether every proxy is accepting connections.
+func (c *Checker) Live() *Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return newReport(c.proxyChecks())
+}
+
+// Ready reports whether the host and the enclave can serve at now: the proxies accept connections, the upstreams were
+// reachable at the last probe, the enclave answered recently and published attested keys recently.
+func (c *Checker) Ready(now time.Time) *Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	checks := c.proxyChecks()
+	for _, target := range c.upstreams {
+		check := Check{Name: "upstream " + target.name, OK: target.probed && target.err == nil}
+		switch {
+		case !target.probed:
+			check.Detail = "not probed yet"
+		case target.err != nil:
+			check.Detail = fmt.Sprintf("%s unreachable: %v", target.address, target.err)
+		default:
+			check.Detail = fmt.Sprintf("%s reachable %s ago", target.address, age(now, target.probedAt))
+		}
+		checks = append(checks, check)
+	}
+	return newReport(append(checks, c.controlCheck(now), c.attestationCheck(now)))
+}
+
+func (c *Checker) proxyChecks() []Check {
+	checks := make([]Check, 0, len(c.proxies))
+	for _, p := range c.proxies {
+		check := Check{Name: "proxy " + p.name, OK: c.listening(p.vsockPort)}
+		if check.OK {
+			check.Detail = fmt.Sprintf("listening on vsock port %d", p.vsockPort)
+		} else {
+			check.Detail = fmt.Sprintf("not listening on vsock port %d", p.vsockPort)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// controlCheck fails once the enclave has not answered for longer than the allowed silence. A single missed probe is
+// tolerated as long as the silence allows for it.
+func (c *Checker) controlCheck(now time.Time) Check {
+	check := Check{Name: "enclave control"}
+	switch {
+	case c.answered.IsZero() && c.statusErr == nil:
+		check.Detail = "not probed yet"
+	case c.answered.IsZero():
+		check.Detail = fmt.Sprintf("never answered: %v", c.statusErr)
+	case now.Sub(c.answered) > c.cfg.MaxControlSilence.Duration:
+		check.Detail = fmt.Sprintf("silent for %s: %v", age(now, c.answered), c.statusErr)
+	default:
+		check.OK = true
+		check.Detail = fmt.Sprintf("answered %s ago", age(now, c.answered))
+	}
+	return check
+}
+
+// attestationCheck fails once the last successful refresh cycle, which published keys vouched for by an attestation,
+// is older than the allowed age. Until the first cycle succeeds the age is counted from the enclave's start.
+func (c *Checker) attestationCheck(now time.Time) Check {
+	check := Check{Name: "attestation"}
+	if c.status == nil {
+		check.Detail = "enclave status unknown"
+		return check
+	}
+
+	since, what := c.status.StartedAt, "no successful cycle since the enclave started"
+	if c.status.LastSuccess != nil {
+		since, what = c.status.LastSuccess.FinishedAt, fmt.Sprintf("last successful cycle %d finished", c.status.LastSuccess.Number)
+	}
+	check.OK = now.Sub(since) <= c.cfg.MaxAttestationAge.Duration
+	check.Detail = fmt.Sprintf("%s %s ago, at most %s allowed", what, age(now, since), c.cfg.MaxAttestationAge)
+	return check
+}
+
+func newReport(checks []Check) *Report {
+	report := &Report{Status: "ok", Checks: checks}
+	for _, check := range checks {
+		if !check.OK {
+			report.Status = "failing"
+		}
+	}
+	return report
+}
+
+func writeReport(w http.ResponseWriter, report *Report) {
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		log.Debugf("Failed to write health report: %v", err)
+	}
+}
+
+func age(now time.Time, since time.Time) time.Duration {
+	return now.Sub(since).Round(time.Second)
+}
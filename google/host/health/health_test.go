@@ -0,0 +1,135 @@
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/config"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/daemon"
+)
+
+func TestReady(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.HealthConfig{
+		MaxControlSilence: config.Duration{Duration: 2 * time.Minute},
+		MaxAttestationAge: config.Duration{Duration: 3 * time.Hour},
+	}
+
+	// healthy returns a checker every check passes on, for the cases to break one check of
+	healthy := func() *Checker {
+		c := New(cfg)
+		c.listening = func(uint32) bool { return true }
+		c.proxies = []proxy{{name: "google", vsockPort: 50001}}
+		c.upstreams = []*upstream{{name: "google", address: "www.googleapis.com:443", probed: true, probedAt: now.Add(-10 * time.Second)}}
+		c.answered = now.Add(-30 * time.Second)
+		c.status = &control.Status{
+			StartedAt:   now.Add(-24 * time.Hour),
+			LastSuccess: &daemon.Cycle{Number: 7, FinishedAt: now.Add(-time.Hour)},
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Checker)
+		failed string // the check expected to fail, empty if all pass
+		detail string
+	}{
+		{
+			name:   "healthy",
+			change: func(c *Checker) {},
+		},
+		{
+			name:   "proxy down",
+			change: func(c *Checker) { c.listening = func(uint32) bool { return false } },
+			failed: "proxy google",
+			detail: "not listening on vsock port 50001",
+		},
+		{
+			name:   "upstream not probed",
+			change: func(c *Checker) { c.upstreams[0].probed = false },
+			failed: "upstream google",
+			detail: "not probed yet",
+		},
+		{
+			name:   "upstream failing",
+			change: func(c *Checker) { c.upstreams[0].err = errors.New("connection refused") },
+			failed: "upstream google",
+			detail: "www.googleapis.com:443 unreachable: connection refused",
+		},
+		{
+			name: "control silent too long",
+			change: func(c *Checker) {
+				c.answered = now.Add(-3 * time.Minute)
+				c.statusErr = errors.New("timeout")
+			},
+			failed: "enclave control",
+			detail: "silent for 3m0s: timeout",
+		},
+		{
+			name: "control silence within limit",
+			change: func(c *Checker) {
+				c.answered = now.Add(-2 * time.Minute)
+				c.statusErr = errors.New("timeout")
+			},
+		},
+		{
+			name:   "control never answered",
+			change: func(c *Checker) { c.answered, c.statusErr = time.Time{}, errors.New("timeout") },
+			failed: "enclave control",
+			detail: "never answered: timeout",
+		},
+		{
+			name:   "attestation too old",
+			change: func(c *Checker) { c.status.LastSuccess.FinishedAt = now.Add(-4 * time.Hour) },
+			failed: "attestation",
+			detail: "last successful cycle 7 finished 4h0m0s ago",
+		},
+		{
+			name: "no successful cycle, started recently",
+			change: func(c *Checker) {
+				c.status.StartedAt, c.status.LastSuccess = now.Add(-time.Hour), nil
+			},
+		},
+		{
+			name:   "no successful cycle since start",
+			change: func(c *Checker) { c.status.LastSuccess = nil },
+			failed: "attestation",
+			detail: "no successful cycle since the enclave started 24h0m0s ago",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := healthy()
+			test.change(c)
+			report := c.Ready(now)
+
+			var failed []Check
+			for _, check := range report.Checks {
+				if !check.OK {
+					failed = append(failed, check)
+				}
+			}
+
+			if test.failed == "" {
+				if report.Status != "ok" || len(failed) > 0 {
+					t.Fatalf("expected ready, got %s with failing checks %+v", report.Status, failed)
+				}
+				return
+			}
+			if report.Status != "failing" {
+				t.Fatalf("expected failing, got %s", report.Status)
+			}
+			if len(failed) != 1 || failed[0].Name != test.failed {
+				t.Fatalf("expected only %q to fail, got %+v", test.failed, failed)
+			}
+			if !strings.Contains(failed[0].Detail, test.detail) {
+				t.Errorf("detail %q does not contain %q", failed[0].Detail, test.detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package logcollector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/logstream"
+	"github.com/EkamSinghPandher/Tee-Google/vsock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	linesWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "log_collector_lines_total",
+		Help: "Enclave log entries written to the output.",
+	})
+	linesRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "log_collector_rejected_lines_total",
+		Help: "Lines from the enclave that were not a JSON object or too long, and were dropped.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(linesWritten, linesRejected)
+}
+
+// Collector writes the log entries the enclave streams over vsock to an output as JSON lines, one entry per line.
+type Collector struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// InitVsockLogCollector appends the enclave log entries received on vsockPort to output (a file path, or - for
+// stdout) until ctx is done.
+func InitVsockLogCollector(ctx context.Context, vsockPort uint32, output string) error {
+	out := io.Writer(os.Stdout)
+	if output != "-" {
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			return fmt.Errorf("failed to open log output: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	listener, err := vsock.Listen(vsockPort, nil)
+	if err != nil {
+		return fmt.Errorf("log collector failed to listen on vsock port %d: %v", vsockPort, err)
+	}
+	log.Infof("Collecting enclave logs from vsock port %d into %s", vsockPort, output)
+	return (&Collector{out: out}).Serve(ctx, listener)
+}
+
+// Serve reads entries from every connection accepted on listener until ctx is done.
+func (c *Collector) Serve(ctx context.Context, listener net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("log collector accept failed: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			stopConn := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stopConn()
+			c.collect(conn)
+		}()
+	}
+}
+
+func (c *Collector) collect(conn net.Conn) {
+	lines := bufio.NewScanner(conn)
+	lines.Buffer(make([]byte, 4096), logstream.MaxEntrySize)
+
+	for lines.Scan() {
+		line := lines.Bytes()
+		if !json.Valid(line) || len(line) == 0 || line[0] != '{' {
+			linesRejected.Inc()
+			continue
+		}
+
+		c.mu.Lock()
+		_, err := c.out.Write(append(line, '\n'))
+		c.mu.Unlock()
+		if err != nil {
+			log.Errorf("Failed to write enclave log entry: %v", err)
+			return
+		}
+		linesWritten.Inc()
+	}
+
+	if err := lines.Err(); err != nil {
+		// A line above the limit ends the connection, the enclave reconnects
+		linesRejected.Inc()
+		log.Warnf("Enclave log stream closed: %v", err)
+	}
+}
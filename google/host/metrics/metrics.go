@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/control"
+	"github.com/EkamSinghPandher/Tee-Google/google/enclave/network"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ScrapeTimeout bounds reading the enclave metrics, so a stuck enclave does not stall the host's own metrics.
+var ScrapeTimeout = 5 * time.Second
+
+var enclaveUp = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "enclave_metrics_up",
+	Help: "Whether the last read of the enclave metrics over vsock succeeded.",
+})
+
+func init() {
+	prometheus.MustRegister(enclaveUp)
+}
+
+// enclaveGatherer reads the enclave metrics from the control server of the enclave on every scrape.
+type enclaveGatherer struct {
+	httpClient *http.Client
+}
+
+func (g *enclaveGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gather()
+	if err != nil {
+		enclaveUp.Set(0)
+		log.Warnf("Failed to read enclave metrics: %v", err)
+		return nil, err
+	}
+	enclaveUp.Set(1)
+	return families, nil
+}
+
+func (g *enclaveGatherer) gather() ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://enclave"+control.MetricsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("enclave returned %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse enclave metrics: %v", err)
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, family := range parsed {
+		families = append(families, family)
+	}
+	return families, nil
+}
+
+// Handler serves the host metrics together with the enclave metrics, which are read from the control server the
+// enclave enclaveCID serves on vsockPort. If the enclave cannot be reached the host metrics are still served.
+func Handler(enclaveCID uint32, vsockPort uint32) http.Handler {
+	enclave := &enclaveGatherer{
+		httpClient: &http.Client{
+			Transport: &network.VsockHTTPRoundTripper{
+				CID:  enclaveCID,
+				Port: vsockPort,
+			},
+		},
+	}
+
+	// The enclave comes first so enclave_metrics_up is current in the same scrape
+	gatherers := prometheus.Gatherers{enclave, prometheus.DefaultGatherer}
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
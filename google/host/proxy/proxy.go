@@ -9,9 +9,9 @@ import (
 )
 
 // This function listens to the Vsock port provided and forwards the traffic to the TCP port provided. it will forward all traffic from this vsock port to
-// the URL provided.
-func InitVsockToTcpProxy(ctx context.Context, vsockPort uint32, tcpPort uint32, forwardUrl string) {
+// the URL provided. It returns once ctx is done and the connections in flight have drained.
+func InitVsockToTcpProxy(ctx context.Context, vsockPort uint32, tcpPort uint32, forwardUrl string) error {
 	log.Infof("Listening to vsock at port: %v", vsockPort)
 	log.Infof("Forwarding tcp to %s:%v", forwardUrl, tcpPort)
-	vsockproxy.NewVsockProxy(ctx, forwardUrl, tcpPort, vsockPort)
+	return vsockproxy.NewVsockProxy(ctx, forwardUrl, tcpPort, vsockPort)
 }
@@ -0,0 +1,105 @@
+package relayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	client "github.com/EkamSinghPandher/Tee-Google/google/enclave/_client"
+	"github.com/EkamSinghPandher/Tee-Google/vsock"
+	"github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Relayer submits the attestations and signed updates it receives from the enclave with a key held by the host, and
+// answers with the outcome once the transaction is confirmed.
+type Relayer struct {
+	queue *client.SubmissionQueue
+}
+
+// ShutdownTimeout is how long submissions in flight when the relayer is stopped may take to answer. Submissions cut
+// off are in the queue file and resumed on the next start.
+var ShutdownTimeout = 10 * time.Second
+
+// InitVsockRelayer signs with the profile's key, talks to the profile's RPC directly and serves the enclave on
+// vsockPort, until ctx is done.
+func InitVsockRelayer(ctx context.Context, vsockPort uint32, profile *client.ChainProfile) error {
+	ethClient, err := ethclient.DialContext(ctx, profile.RPCURL)
+	if err != nil {
+		return fmt.Errorf("relayer failed to dial %s: %v", profile.RPCURL, err)
+	}
+
+	queue, err := client.NewSubmissionQueue(ctx, ethClient, profile)
+	if err != nil {
+		return fmt.Errorf("relayer failed to open submission queue: %v", err)
+	}
+	relayer := &Relayer{queue: queue}
+
+	listener, err := vsock.Listen(vsockPort, nil)
+	if err != nil {
+		return fmt.Errorf("relayer failed to listen on vsock port %d: %v", vsockPort, err)
+	}
+	traced := tracecontext.Listener(listener)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(client.RelayerPath, relayer.handleSubmit)
+	// Requests carry the trace context of the caller ahead of them
+	server := &http.Server{Handler: tracecontext.Handler("relayer", mux), ConnContext: tracecontext.ConnContext}
+
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		stopped <- server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Relayer for chain profile %s listening to vsock at port: %v", profile.Name, vsockPort)
+	if err := server.Serve(traced); err != http.ErrServerClosed {
+		return fmt.Errorf("relayer stopped: %v", err)
+	}
+
+	if err := <-stopped; err != nil {
+		server.Close()
+		return fmt.Errorf("relayer did not finish its submissions in time: %w", err)
+	}
+	log.Infof("Relayer for chain profile %s stopped", profile.Name)
+	return nil
+}
+
+func (r *Relayer) handleSubmit(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var relayReq client.RelayRequest
+	body := http.MaxBytesReader(w, req.Body, client.MaxRelayRequestSize)
+	if err := json.NewDecoder(body).Decode(&relayReq); err != nil {
+		http.Error(w, "invalid relay request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Infof("Relaying %s submission: %d bytes", relayReq.Kind, len(relayReq.Payload))
+
+	var relayResp client.RelayResponse
+	relayResp.Outcome, relayResp.Error = r.submit(req.Context(), relayReq.Kind, relayReq.Payload)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&relayResp); err != nil {
+		log.Errorf("Failed to send relay response: %v", err)
+	}
+}
+
+func (r *Relayer) submit(ctx context.Context, kind string, payload []byte) (*client.SubmissionOutcome, string) {
+	outcome, err := r.queue.Submit(ctx, kind, payload)
+	if err != nil {
+		log.Errorf("Relayed %s submission failed: %v", kind, err)
+		return outcome, err.Error()
+	}
+	return outcome, ""
+}
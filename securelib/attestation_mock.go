@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/hf/nitrite"
 )
 
@@ -29,7 +30,7 @@ func GetManager() *mockManager {
 	return mgr
 }
 
-func (m *mockManager) Attest(pubKey []byte, userData []byte) ([]byte, error) {
+func (m *mockManager) Attest(pubKey []byte, userData []byte, nonce []byte) ([]byte, error) {
 	// mockPkStr := strings.TrimPrefix(mockIdentityPKHex, "0x")
 	// mockPkBuf, _ := hex.DecodeString(mockPkStr)
 
@@ -45,7 +46,15 @@ func (m *mockManager) Attest(pubKey []byte, userData []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed to decode mock attestation: %w", err)
 	}
 
-	attestation, err = InjectCustomDataIntoAttestation(attestation, userData)
+	fields := map[string]interface{}{"user_data": userData}
+	if pubKey != nil {
+		fields["public_key"] = pubKey
+	}
+	if nonce != nil {
+		fields["nonce"] = nonce
+	}
+
+	attestation, err = InjectFieldsIntoAttestation(attestation, fields)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inject custom data into attestation: %w", err)
 	}
@@ -59,18 +68,38 @@ func (m *mockManager) Parse(doc []byte) (*Doc, error) {
 		return nil, fmt.Errorf("verify attestation doc err: %w", err)
 	}
 
-	earliest := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	var header struct {
+		Alg int64 `cbor:"1,keyasint"`
+	}
+	if err := cbor.Unmarshal(rst.Protected, &header); err != nil {
+		return nil, fmt.Errorf("parse protected header err: %w", err)
+	}
+
 	pcrs := map[string][]byte{}
 	for idx, pcr := range rst.Document.PCRs {
 		idxStr := strconv.Itoa(int(idx))
 		pcrs[idxStr] = pcr
 	}
+
+	// Certificates[0] is the enclave certificate, Verify already parsed it
+	expiry := rst.Certificates[0].NotAfter
+
 	return &Doc{
-		RawData:    doc,
-		PubKey:     rst.Document.PublicKey,
-		UserData:   rst.Document.UserData,
-		PCRs:       pcrs,
-		ExpiryTime: earliest,
+		RawData: doc,
+		Protected: ProtectedHeader{
+			Raw:       rst.Protected,
+			Algorithm: header.Alg,
+		},
+		ModuleID:    rst.Document.ModuleID,
+		Digest:      rst.Document.Digest,
+		Timestamp:   time.UnixMilli(int64(rst.Document.Timestamp)).UTC(),
+		PCRs:        pcrs,
+		Certificate: rst.Document.Certificate,
+		CABundle:    rst.Document.CABundle,
+		PubKey:      rst.Document.PublicKey,
+		UserData:    rst.Document.UserData,
+		Nonce:       rst.Document.Nonce,
+		ExpiryTime:  expiry,
 	}, nil
 }
 
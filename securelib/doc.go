@@ -2,37 +2,112 @@ package securelib
 
 import (
 	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	jsoniter "github.com/json-iterator/go"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// Doc is a parsed attestation document. Field names in the JSON and CBOR encodings follow the attestation document
+// itself, except RawData which is the whole COSE_Sign1 structure.
 type Doc struct {
-	RawData    []byte
-	PubKey     []byte
-	UserData   []byte
-	PCRs       map[string][]byte
-	ExpiryTime time.Time
+	RawData     []byte            `json:"raw_data" cbor:"raw_data"`
+	Protected   ProtectedHeader   `json:"protected" cbor:"protected"`
+	ModuleID    string            `json:"module_id" cbor:"module_id"`
+	Digest      string            `json:"digest" cbor:"digest"`
+	Timestamp   time.Time         `json:"timestamp" cbor:"timestamp"`
+	PCRs        map[string][]byte `json:"pcrs" cbor:"pcrs"`
+	Certificate []byte            `json:"certificate" cbor:"certificate"`
+	CABundle    [][]byte          `json:"cabundle" cbor:"cabundle"`
+	PubKey      []byte            `json:"public_key,omitempty" cbor:"public_key,omitempty"`
+	UserData    []byte            `json:"user_data,omitempty" cbor:"user_data,omitempty"`
+	Nonce       []byte            `json:"nonce,omitempty" cbor:"nonce,omitempty"`
+	// NotAfter of the enclave certificate, the document cannot be verified after this
+	ExpiryTime time.Time `json:"expiry_time" cbor:"expiry_time"`
+}
+
+// ProtectedHeader is the COSE protected header of the attestation.
+type ProtectedHeader struct {
+	Raw       []byte `json:"raw" cbor:"raw"`
+	Algorithm int64  `json:"alg" cbor:"alg"` // COSE algorithm identifier, -35 for ES384
+}
+
+// AlgorithmName returns the COSE name of the signing algorithm.
+func (h ProtectedHeader) AlgorithmName() string {
+	switch h.Algorithm {
+	case -35:
+		return "ES384"
+	case -36:
+		return "ES512"
+	case -7:
+		return "ES256"
+	default:
+		return strconv.FormatInt(h.Algorithm, 10)
+	}
+}
+
+type debugPCR struct {
+	Index int    `json:"index"`
+	Value string `json:"value"`
 }
 
 func (d *Doc) Debug() string {
-	docPRCs := map[string]string{}
-	for k, v := range d.PCRs {
-		// show PCR0 only in the debug output
-		if k == "0" {
-			docPRCs[k] = "0x" + hex.EncodeToString(v)
-			break
+	pcrIndexes := make([]int, 0, len(d.PCRs))
+	for k := range d.PCRs {
+		idx, err := strconv.Atoi(k)
+		if err == nil {
+			pcrIndexes = append(pcrIndexes, idx)
 		}
 	}
+	sort.Ints(pcrIndexes)
+
+	// A list rather than a map, which would be printed in string order with 10 before 2
+	docPCRs := make([]debugPCR, 0, len(pcrIndexes))
+	for _, idx := range pcrIndexes {
+		docPCRs = append(docPCRs, debugPCR{Index: idx, Value: "0x" + hex.EncodeToString(d.PCRs[strconv.Itoa(idx)])})
+	}
+
+	caBundle := make([]string, 0, len(d.CABundle))
+	for _, cert := range d.CABundle {
+		caBundle = append(caBundle, "0x"+hex.EncodeToString(cert))
+	}
+
 	docMap := map[string]any{
-		"rawData":    "0x" + hex.EncodeToString(d.RawData),
-		"pubKey":     "0x" + hex.EncodeToString(d.PubKey),
-		"userData":   "0x" + hex.EncodeToString(d.UserData),
-		"pcrs":       docPRCs,
-		"expiryTime": d.ExpiryTime.Format(time.RFC3339),
+		"rawData": "0x" + hex.EncodeToString(d.RawData),
+		"protected": map[string]any{
+			"raw": "0x" + hex.EncodeToString(d.Protected.Raw),
+			"alg": d.Protected.AlgorithmName(),
+		},
+		"moduleId":    d.ModuleID,
+		"digest":      d.Digest,
+		"timestamp":   d.Timestamp.Format(time.RFC3339Nano),
+		"pcrs":        docPCRs,
+		"certificate": "0x" + hex.EncodeToString(d.Certificate),
+		"cabundle":    caBundle,
+		"pubKey":      "0x" + hex.EncodeToString(d.PubKey),
+		"userData":    "0x" + hex.EncodeToString(d.UserData),
+		"nonce":       "0x" + hex.EncodeToString(d.Nonce),
+		"expiryTime":  d.ExpiryTime.Format(time.RFC3339),
 	}
 	buf, _ := json.MarshalIndent(docMap, "", "  ")
 	return string(buf)
 }
+
+var docEncMode = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{Sort: cbor.SortCanonical, Time: cbor.TimeRFC3339Nano}.EncMode()
+	if err != nil {
+		panic(fmt.Sprintf("invalid attestation document CBOR options: %v", err))
+	}
+	return mode
+}()
+
+// MarshalCBOR keeps the millisecond precision of the timestamp, which the default unix seconds encoding would drop.
+func (d *Doc) MarshalCBOR() ([]byte, error) {
+	type plain Doc
+	return docEncMode.Marshal((*plain)(d))
+}
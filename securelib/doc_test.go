@@ -0,0 +1,129 @@
+package securelib
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func parseMock(t *testing.T) (*Doc, []byte) {
+	attestation, err := new(mockManager).Attest([]byte{0x04, 0x01}, []byte("user data"), []byte("nonce"))
+	if err != nil {
+		t.Fatalf("Failed to attest: %v", err)
+	}
+	doc, err := new(mockManager).Parse(attestation)
+	if err != nil {
+		t.Fatalf("Failed to parse attestation: %v", err)
+	}
+	return doc, attestation
+}
+
+func TestParse(t *testing.T) {
+	doc, attestation := parseMock(t)
+
+	if doc.ModuleID != "i-018b64d29a5dbc684-enc0195a73e7b93c276" || doc.Digest != "SHA384" {
+		t.Errorf("Unexpected module %q and digest %q", doc.ModuleID, doc.Digest)
+	}
+	if doc.Protected.Algorithm != -35 || doc.Protected.AlgorithmName() != "ES384" || len(doc.Protected.Raw) == 0 {
+		t.Errorf("Unexpected protected header %+v", doc.Protected)
+	}
+	if want := time.UnixMilli(0x195a74778db).UTC(); !doc.Timestamp.Equal(want) || doc.Timestamp.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Errorf("Timestamp %s, want %s", doc.Timestamp, want)
+	}
+	if len(doc.PCRs) != 16 || len(doc.PCRs["4"]) != 48 {
+		t.Errorf("Expected 16 PCRs of 48 bytes, got %d", len(doc.PCRs))
+	}
+	if len(doc.Certificate) == 0 || len(doc.CABundle) != 4 {
+		t.Errorf("Expected a certificate and 4 CA certificates, got %d", len(doc.CABundle))
+	}
+	if !bytes.Equal(doc.PubKey, []byte{0x04, 0x01}) || string(doc.UserData) != "user data" || string(doc.Nonce) != "nonce" {
+		t.Errorf("Injected fields not parsed: %x %q %q", doc.PubKey, doc.UserData, doc.Nonce)
+	}
+	if !bytes.Equal(doc.RawData, attestation) {
+		t.Errorf("Raw data is not the parsed attestation")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	doc, _ := parseMock(t)
+
+	// The enclave certificate of the mock document expired, which Parse tolerates but reports
+	if want := time.Date(2025, 3, 18, 6, 23, 53, 0, time.UTC); !doc.ExpiryTime.Equal(want) {
+		t.Errorf("Expiry %s, want %s", doc.ExpiryTime, want)
+	}
+	if !doc.ExpiryTime.Before(time.Now()) {
+		t.Errorf("Expiry %s should be in the past", doc.ExpiryTime)
+	}
+
+	// Other verification errors are not tolerated
+	for _, invalid := range [][]byte{nil, {0x84, 0x40}, MockDoc()[:100]} {
+		if _, err := new(mockManager).Parse(invalid); err == nil {
+			t.Errorf("Parsed invalid document %x", invalid)
+		}
+	}
+}
+
+func TestDocRoundTrip(t *testing.T) {
+	doc, _ := parseMock(t)
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	var fromJSON Doc
+	if err := json.Unmarshal(encoded, &fromJSON); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if !reflect.DeepEqual(doc, &fromJSON) {
+		t.Errorf("JSON round trip changed the document:\n%s\n%s", doc.Debug(), fromJSON.Debug())
+	}
+
+	encoded, err = doc.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Failed to marshal CBOR: %v", err)
+	}
+	var fromCBOR Doc
+	if err := cbor.Unmarshal(encoded, &fromCBOR); err != nil {
+		t.Fatalf("Failed to unmarshal CBOR: %v", err)
+	}
+	if !fromCBOR.Timestamp.Equal(doc.Timestamp) || !fromCBOR.ExpiryTime.Equal(doc.ExpiryTime) {
+		t.Errorf("CBOR round trip lost time precision: %s -> %s", doc.Timestamp, fromCBOR.Timestamp)
+	}
+	fromCBOR.Timestamp, fromCBOR.ExpiryTime = doc.Timestamp, doc.ExpiryTime
+	if !reflect.DeepEqual(doc, &fromCBOR) {
+		t.Errorf("CBOR round trip changed the document:\n%s\n%s", doc.Debug(), fromCBOR.Debug())
+	}
+}
+
+func TestDebugKeepsPCROrder(t *testing.T) {
+	doc := &Doc{PCRs: map[string][]byte{"10": {0x0a}, "2": {0x02}, "0": {0x00}}}
+
+	var debug struct {
+		PCRs []struct {
+			Index int    `json:"index"`
+			Value string `json:"value"`
+		} `json:"pcrs"`
+	}
+	if err := json.Unmarshal([]byte(doc.Debug()), &debug); err != nil {
+		t.Fatalf("Failed to parse debug output: %v", err)
+	}
+
+	var indexes []int
+	for _, pcr := range debug.PCRs {
+		indexes = append(indexes, pcr.Index)
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 2, 10}) || debug.PCRs[2].Value != "0x0a" {
+		t.Errorf("PCRs out of order: %+v", debug.PCRs)
+	}
+}
+
+func TestAlgorithmName(t *testing.T) {
+	for alg, name := range map[int64]string{-35: "ES384", -36: "ES512", -7: "ES256", -8: "-8"} {
+		if got := (ProtectedHeader{Algorithm: alg}).AlgorithmName(); got != name {
+			t.Errorf("AlgorithmName(%d) = %s, want %s", alg, got, name)
+		}
+	}
+}
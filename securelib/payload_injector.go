@@ -7,27 +7,28 @@ import (
 )
 
 func InjectCustomDataIntoAttestation(attestationBytes []byte, userDataBytes []byte) ([]byte, error) {
-	var coseArray []interface{}
-	err := cbor.Unmarshal(attestationBytes, &coseArray)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse COSE array: %v", err)
-	}
-	if len(coseArray) != 4 {
-		return nil, fmt.Errorf("COSE array should have 4 elements: {protected, unprotected, payload, signature} but was %v", coseArray)
-	}
+	return InjectFieldsIntoAttestation(attestationBytes, map[string]interface{}{
+		"user_data": userDataBytes,
+	})
+}
 
-	payloadBytes, ok := coseArray[2].([]byte)
-	if !ok {
-		return nil, fmt.Errorf("payload should be a byte array")
-	}
+// ReadAttestationFields returns the decoded attestation document payload without verifying the COSE signature.
+func ReadAttestationFields(attestationBytes []byte) (map[string]interface{}, error) {
+	_, payloadMap, err := decodeCoseSign1(attestationBytes)
+	return payloadMap, err
+}
 
-	var payloadMap map[string]interface{}
-	err = cbor.Unmarshal(payloadBytes, &payloadMap)
+// InjectFieldsIntoAttestation overwrites the given keys of the attestation document payload, e.g. "user_data",
+// "public_key" or "nonce". The COSE signature is left untouched, so the result is only useful for testing.
+func InjectFieldsIntoAttestation(attestationBytes []byte, fields map[string]interface{}) ([]byte, error) {
+	coseArray, payloadMap, err := decodeCoseSign1(attestationBytes)
 	if err != nil {
-		return nil, fmt.Errorf("payload is not a CBOR map: %v", err)
+		return nil, err
 	}
 
-	payloadMap["user_data"] = userDataBytes
+	for key, value := range fields {
+		payloadMap[key] = value
+	}
 
 	newPayloadBytes, err := cbor.Marshal(payloadMap)
 	if err != nil {
@@ -48,3 +49,26 @@ func InjectCustomDataIntoAttestation(attestationBytes []byte, userDataBytes []by
 
 	return newAttestationBytes, nil
 }
+
+func decodeCoseSign1(attestationBytes []byte) ([]interface{}, map[string]interface{}, error) {
+	var coseArray []interface{}
+	err := cbor.Unmarshal(attestationBytes, &coseArray)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse COSE array: %v", err)
+	}
+	if len(coseArray) != 4 {
+		return nil, nil, fmt.Errorf("COSE array should have 4 elements: {protected, unprotected, payload, signature} but was %v", coseArray)
+	}
+
+	payloadBytes, ok := coseArray[2].([]byte)
+	if !ok {
+		return nil, nil, fmt.Errorf("payload should be a byte array")
+	}
+
+	var payloadMap map[string]interface{}
+	err = cbor.Unmarshal(payloadBytes, &payloadMap)
+	if err != nil {
+		return nil, nil, fmt.Errorf("payload is not a CBOR map: %v", err)
+	}
+	return coseArray, payloadMap, nil
+}
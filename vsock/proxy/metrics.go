@@ -0,0 +1,34 @@
+package vsockproxy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics of every proxy, labelled with its route, e.g. vsock:50001->www.googleapis.com:443. They are registered with
+// the default Prometheus registry.
+var (
+	activeConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vsock_proxy_active_connections",
+		Help: "Connections currently being proxied.",
+	}, []string{"route"})
+
+	bytesTransferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vsock_proxy_bytes_total",
+		Help: "Bytes copied, by direction: upstream is from the accepted connection to the dialed one.",
+	}, []string{"route", "direction"})
+
+	dialErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vsock_proxy_dial_errors_total",
+		Help: "Accepted connections dropped because the destination could not be dialed.",
+	}, []string{"route"})
+
+	connectionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vsock_proxy_connection_duration_seconds",
+		Help:    "Time from accepting a connection until both directions are closed.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"route"})
+)
+
+func init() {
+	prometheus.MustRegister(activeConnections, bytesTransferred, dialErrors, connectionDuration)
+}
@@ -7,25 +7,176 @@ import (
 	"io"
 	"net"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/EkamSinghPandher/Tee-Google/vsock"
+	"github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func handle(context context.Context, conn net.Conn, remoteCid, remotePort uint32) {
-	proxy, err := vsock.Dial(remoteCid, remotePort, nil)
-	//log.Printf("proxy Dial remoteCid:%d , remotePort:%d, error:%v", remoteCid, remotePort, err)
+// DrainTimeout is how long connections in flight when a proxy is stopped may keep going before they are cut.
+var DrainTimeout = 10 * time.Second
+
+// ErrDrainTimeout is returned by a stopped proxy whose connections had to be cut.
+var ErrDrainTimeout = fmt.Errorf("connections did not drain in time: %w", context.DeadlineExceeded)
+
+var tracer = otel.Tracer("github.com/EkamSinghPandher/Tee-Google/vsock/proxy")
+
+// listening holds the vsock ports NewVsockProxy is accepting connections on.
+var (
+	listeningMu sync.Mutex
+	listening   = map[uint32]bool{}
+)
+
+// VsockListening reports whether a proxy started by NewVsockProxy is accepting connections on the vsock port.
+func VsockListening(port uint32) bool {
+	listeningMu.Lock()
+	defer listeningMu.Unlock()
+	return listening[port]
+}
+
+func setVsockListening(port uint32, open bool) {
+	listeningMu.Lock()
+	defer listeningMu.Unlock()
+	if open {
+		listening[port] = true
+	} else {
+		delete(listening, port)
+	}
+}
+
+// connTracker keeps the connections of a proxy so they can be drained when it stops.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+func (t *connTracker) track(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conns[conn] = struct{}{}
+}
+
+func (t *connTracker) untrack(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, conn)
+}
+
+// drain waits for the tracked connections to finish, and closes the ones still open after timeout.
+func (t *connTracker) drain(name string, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	t.mu.Lock()
+	log.Infof("%s stopped, draining %d connections", name, len(t.conns))
+	t.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	log.Warnf("%s closing %d connections still open after %s", name, len(t.conns), timeout)
+	for conn := range t.conns {
+		conn.Close()
+	}
+	t.mu.Unlock()
+
+	<-done
+	return ErrDrainTimeout
+}
+
+// serve accepts connections until ctx is done and pipes each one to a connection from dial. Once ctx is done the
+// listener is closed and the connections in flight get DrainTimeout to finish. It returns nil if they did. route names
+// the proxy in logs and metrics.
+func serve(ctx context.Context, route string, listener net.Listener, dial func() (net.Conn, error)) error {
+	tracker := &connTracker{conns: make(map[net.Conn]struct{})}
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return tracker.drain(route, DrainTimeout)
+			}
+			listener.Close()
+			tracker.drain(route, DrainTimeout)
+			return fmt.Errorf("%s accept failed: %v", route, err)
+		}
+
+		tracker.wg.Add(1)
+		go func() {
+			defer tracker.wg.Done()
+			tracker.pipe(route, conn, dial)
+		}()
+	}
+}
+
+// pipe copies between conn and a connection from dial until both directions are done. Connections that carry a
+// trace context get a span in that trace.
+func (t *connTracker) pipe(route string, conn net.Conn, dial func() (net.Conn, error)) {
+	t.track(conn)
+	defer t.untrack(conn)
+
+	activeConnections.WithLabelValues(route).Inc()
+	defer activeConnections.WithLabelValues(route).Dec()
+	start := time.Now()
+	defer func() { connectionDuration.WithLabelValues(route).Observe(time.Since(start).Seconds()) }()
+
+	span := trace.SpanFromContext(context.Background())
+	if traced, ok := conn.(*tracecontext.Conn); ok {
+		if ctx := traced.Context(context.Background()); trace.SpanContextFromContext(ctx).IsValid() {
+			_, span = tracer.Start(ctx, "proxy "+route, trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("vsock_proxy.route", route)))
+			defer span.End()
+		}
+	}
+
+	proxy, err := dial()
 	if err != nil {
+		dialErrors.WithLabelValues(route).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "dial failed")
 		log.Error(errors.New("handle failed to connect" + err.Error()))
+		conn.Close()
 		return
 	}
+	t.track(proxy)
+	defer t.untrack(proxy)
 
-	go forward(context, conn, proxy, true)
-	go forward(context, proxy, conn, false)
+	var upstream int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		upstream = forward(conn, proxy, true)
+		bytesTransferred.WithLabelValues(route, "upstream").Add(float64(upstream))
+	}()
+	downstream := forward(proxy, conn, false)
+	bytesTransferred.WithLabelValues(route, "downstream").Add(float64(downstream))
+	<-done
+	span.SetAttributes(attribute.Int64("vsock_proxy.bytes_upstream", upstream),
+		attribute.Int64("vsock_proxy.bytes_downstream", downstream))
 }
 
-func forward(context context.Context, source, destination net.Conn, close bool) {
+// forward copies from source to destination and returns the number of bytes copied.
+func forward(source, destination net.Conn, close bool) (copied int64) {
 	log.Info("forwarding")
 	log.Infof("Source: %v -> Destination: %v", source.RemoteAddr(), destination.RemoteAddr())
 
@@ -47,83 +198,57 @@ func forward(context context.Context, source, destination net.Conn, close bool)
 	} else {
 		log.Infof("Copy completed: %d bytes transferred", copied)
 	}
+	return copied
 }
 
-func NewProxy(context context.Context, localPort, remoteCid, remotePort uint32) {
+// NewProxy listens to tcp connections on localPort and forwards them to the vsock at remoteCid:remotePort, until ctx
+// is done.
+func NewProxy(ctx context.Context, localPort, remoteCid, remotePort uint32) error {
 	log.Info("new proxy", " localPort", localPort, " remoteCid", remoteCid, " remotePort", remotePort)
 	local, err := net.Listen("tcp", fmt.Sprintf(":%d", localPort))
 	if err != nil {
-		log.Error(fmt.Sprintf("NewProxy fail to listen :%d,error:%v", localPort, err))
-		return
-	}
-	for {
-		conn, err := local.Accept()
-		if err != nil {
-			log.Errorf("NewProxy Accept failed,localPort %d error: %s", localPort, err.Error())
-			return
-		}
-		//log.Printf("conn Accept,local:%s,remote :%s", local.Addr().String(), conn.RemoteAddr().String())
-		go handle(context, conn, remoteCid, remotePort)
+		return fmt.Errorf("NewProxy fail to listen :%d,error:%v", localPort, err)
 	}
+	return serve(ctx, fmt.Sprintf("tcp:%d->vsock:%d:%d", localPort, remoteCid, remotePort), local, func() (net.Conn, error) {
+		return vsock.Dial(remoteCid, remotePort, nil)
+	})
 }
 
 // This is for the host, it listens to the vsock and forwards anything to the tcp endpoint. Local port is the vsock port, while the remote port
-// is the port of the remote url
-func NewVsockProxy(context context.Context, remoteHost string, remotePort uint32, localPort uint32) {
+// is the port of the remote url. It returns once ctx is done and the connections have drained.
+func NewVsockProxy(ctx context.Context, remoteHost string, remotePort uint32, localPort uint32) error {
 	local, err := vsock.Listen(localPort, nil)
 	if err != nil {
-		log.Error(fmt.Sprintf("NewVsockProxy fail to listen :%d,error:%v", localPort, err))
-		return
+		return fmt.Errorf("NewVsockProxy fail to listen :%d,error:%v", localPort, err)
 	}
-	for {
-		conn, err := local.Accept()
-		log.Infof("Accepted connection from vsock")
-		if err != nil {
-			log.Errorf("NewVsockProxy Accept failed,localPort %d error: %s", localPort, err.Error())
-			return
-		}
-		// log.Printf("conn Accept,local:%s,remote :%s", local.Addr().String(), conn.RemoteAddr().String())
-
-		go handleVsock(context, conn, remoteHost, remotePort)
+	// The enclave sends the trace context of its requests ahead of them
+	traced := tracecontext.Listener(local)
 
+	hostname := remoteHost
+	if strings.HasPrefix(hostname, "https://") {
+		hostname = strings.TrimPrefix(hostname, "https://")
+	} else if strings.HasPrefix(hostname, "http://") {
+		hostname = strings.TrimPrefix(hostname, "http://")
 	}
+
+	setVsockListening(localPort, true)
+	defer setVsockListening(localPort, false)
+	return serve(ctx, fmt.Sprintf("vsock:%d->%s:%d", localPort, hostname, remotePort), traced, func() (net.Conn, error) {
+		log.Infof("Accepted connection from vsock, connecting to %s:%d", hostname, remotePort)
+		return net.Dial("tcp", net.JoinHostPort(hostname, fmt.Sprintf("%d", remotePort)))
+	})
 }
 
 // This listens to the all tcp connections at a specific port and forwards it to the vsock. In this function,
 // the remotePort is the port of the vsock and the localPort is the port at which we want the enclave to listen to for tcp connections.
 // For example, if the enclave is sending a tcp connection accross http to example.com, the local port is 80(http) and the remotePort is the port of the vsock.
 // remoteCid should be 3.
-func NewSocat(context context.Context, remoteCid uint32, remotePort uint32, localPort uint32) {
+func NewSocat(ctx context.Context, remoteCid uint32, remotePort uint32, localPort uint32) error {
 	local, err := net.Listen("tcp", fmt.Sprintf(":%d", localPort))
 	if err != nil {
-		log.Error(fmt.Sprintf("NewSocat fail to listen :%d,error:%v", localPort, err))
-		return
-	}
-	for {
-		conn, err := local.Accept()
-		if err != nil {
-			log.Errorf("NewSocat Accept failed,localPort %d error: %s", localPort, err.Error())
-			return
-		}
-		// log.Printf("conn Accept,local:%s,remote :%s", local.Addr().String(), conn.RemoteAddr().String())
-		go handle(context, conn, remoteCid, remotePort)
-	}
-}
-
-func handleVsock(context context.Context, conn net.Conn, remoteHost string, remotePort uint32) {
-	hostname := remoteHost
-	if strings.HasPrefix(hostname, "https://") {
-		hostname = strings.TrimPrefix(hostname, "https://")
-	} else if strings.HasPrefix(hostname, "http://") {
-		hostname = strings.TrimPrefix(hostname, "http://")
-	}
-
-	log.Infof("Connecting to %s:%d", hostname, remotePort)
-	proxy, err := net.Dial("tcp", net.JoinHostPort(hostname, fmt.Sprintf("%d", remotePort)))
-	if err != nil {
-		log.Error(errors.New("handle failed to connect" + err.Error()))
-		return
+		return fmt.Errorf("NewSocat fail to listen :%d,error:%v", localPort, err)
 	}
-	go forward(context, conn, proxy, true)
-	go forward(context, proxy, conn, false)
+	return serve(ctx, fmt.Sprintf("tcp:%d->vsock:%d:%d", localPort, remoteCid, remotePort), local, func() (net.Conn, error) {
+		return vsock.Dial(remoteCid, remotePort, nil)
+	})
 }
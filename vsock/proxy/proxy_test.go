@@ -0,0 +1,218 @@
+package vsockproxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// echoServer echoes every line it reads until the connection is closed.
+func echoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	return listener
+}
+
+func startProxy(t *testing.T, ctx context.Context, upstream string) (string, chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, "test proxy", listener, func() (net.Conn, error) {
+			return net.Dial("tcp", upstream)
+		})
+	}()
+	return listener.Addr().String(), done
+}
+
+func roundTrip(t *testing.T, conn net.Conn) {
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "ping\n" {
+		t.Fatalf("Unexpected echo %q: %v", line, err)
+	}
+}
+
+func TestServeDrainsConnections(t *testing.T) {
+	upstream := echoServer(t)
+	defer upstream.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, done := startProxy(t, ctx, upstream.Addr().String())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	roundTrip(t, conn)
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// The listener is closed but the connection in flight keeps working until it is done
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatalf("Proxy still accepts connections after it was stopped")
+	}
+	roundTrip(t, conn)
+	conn.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected a clean drain, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Proxy did not stop after its connections finished")
+	}
+}
+
+func TestServeCutsConnectionsAfterDrainTimeout(t *testing.T) {
+	upstream := echoServer(t)
+	defer upstream.Close()
+
+	drainTimeout := DrainTimeout
+	DrainTimeout = 50 * time.Millisecond
+	defer func() { DrainTimeout = drainTimeout }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, done := startProxy(t, ctx, upstream.Addr().String())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	defer conn.Close()
+	roundTrip(t, conn)
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrDrainTimeout) {
+			t.Fatalf("Expected ErrDrainTimeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Proxy did not cut the connection")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("Connection still open after the drain timeout")
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	upstream := echoServer(t)
+	defer upstream.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	route := fmt.Sprintf("metrics test %d", time.Now().UnixNano())
+	dials := 0
+	go serve(ctx, route, listener, func() (net.Conn, error) {
+		dials++
+		if dials == 2 {
+			return nil, errors.New("upstream unavailable")
+		}
+		return net.Dial("tcp", upstream.Addr().String())
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	roundTrip(t, conn)
+	if active := testutil.ToFloat64(activeConnections.WithLabelValues(route)); active != 1 {
+		t.Errorf("Expected 1 active connection, got %v", active)
+	}
+	conn.Close()
+
+	// The second connection cannot be dialed through and is dropped
+	conn, err = net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	conn.Read(make([]byte, 1))
+	conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(activeConnections.WithLabelValues(route)) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"active connections", testutil.ToFloat64(activeConnections.WithLabelValues(route)), 0},
+		{"upstream bytes", testutil.ToFloat64(bytesTransferred.WithLabelValues(route, "upstream")), 5},
+		{"downstream bytes", testutil.ToFloat64(bytesTransferred.WithLabelValues(route, "downstream")), 5},
+		{"dial errors", testutil.ToFloat64(dialErrors.WithLabelValues(route)), 1},
+	} {
+		if c.got != c.want {
+			t.Errorf("Expected %v %s, got %v", c.want, c.name, c.got)
+		}
+	}
+}
+
+func TestServeStripsTraceContext(t *testing.T) {
+	upstream := echoServer(t)
+	defer upstream.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serve(ctx, "test proxy", tracecontext.Listener(listener), func() (net.Conn, error) {
+		return net.Dial("tcp", upstream.Addr().String())
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial proxy: %v", err)
+	}
+	defer conn.Close()
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	if err := tracecontext.Write(trace.ContextWithSpanContext(ctx, spanContext), conn); err != nil {
+		t.Fatalf("Failed to write trace context: %v", err)
+	}
+	// The upstream only gets the payload, the echo would show the preamble otherwise
+	roundTrip(t, conn)
+}
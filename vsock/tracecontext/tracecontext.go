@@ -0,0 +1,151 @@
+// Package tracecontext carries the W3C trace context of a request across a vsock connection. The byte streams the
+// host proxies are TLS or plain HTTP it cannot look into, so the dialing side sends the trace context in a preamble
+// line ahead of the payload and the accepting side strips it before anything else reads the connection.
+package tracecontext
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// marker starts a preamble. A zero byte never starts a TLS record or an HTTP request, so a listener can tell a
+// preamble from the payload.
+const marker = 0x00
+
+// maxPreambleSize bounds the preamble line, a traceparent is 55 bytes and the tracestate is capped at 32 entries.
+const maxPreambleSize = 1024
+
+var propagator = propagation.TraceContext{}
+
+// Write sends the trace context of ctx to the peer. Nothing is sent if ctx carries no span, so connections without
+// a trace look exactly as before.
+func Write(ctx context.Context, w io.Writer) error {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	parent := carrier.Get("traceparent")
+	if parent == "" {
+		return nil
+	}
+
+	line := string([]byte{marker}) + parent
+	if state := carrier.Get("tracestate"); state != "" {
+		line += " " + state
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		return fmt.Errorf("failed to send trace context: %v", err)
+	}
+	return nil
+}
+
+// Conn is an accepted connection that hides the preamble from its readers.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+
+	once        sync.Once
+	spanContext trace.SpanContext
+	err         error
+}
+
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{Conn: conn, reader: bufio.NewReaderSize(conn, maxPreambleSize)}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	if err := c.readPreamble(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(p)
+}
+
+// Context returns ctx with the trace context the peer sent as the remote parent, or ctx as is if the peer sent
+// none. Unless the connection was read already, it waits for the first bytes from the peer.
+func (c *Conn) Context(ctx context.Context) context.Context {
+	if c.readPreamble() != nil || !c.spanContext.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, c.spanContext)
+}
+
+func (c *Conn) readPreamble() error {
+	c.once.Do(func() {
+		first, err := c.reader.Peek(1)
+		if err != nil || first[0] != marker {
+			// Errors are left to the first read, which gets them again
+			return
+		}
+
+		line, err := c.reader.ReadSlice('\n')
+		if err != nil {
+			c.err = fmt.Errorf("invalid trace context preamble: %v", err)
+			return
+		}
+		fields := strings.Fields(string(line[1:]))
+		carrier := propagation.MapCarrier{}
+		if len(fields) > 0 {
+			carrier.Set("traceparent", fields[0])
+		}
+		if len(fields) > 1 {
+			carrier.Set("tracestate", fields[1])
+		}
+		c.spanContext = trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+	})
+	return c.err
+}
+
+// listener wraps every accepted connection in a Conn.
+type listener struct {
+	net.Listener
+}
+
+// Listener strips the preamble from the connections accepted by l.
+func Listener(l net.Listener) net.Listener {
+	return &listener{Listener: l}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn), nil
+}
+
+type connKey struct{}
+
+// ConnContext is an http.Server ConnContext hook that lets Handler find the connection of a request.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// Handler serves the requests of a server with a Listener and ConnContext that arrive with a trace context in a span
+// named after the server and the request, as a child of the peer's span. Requests without one are not traced.
+func Handler(name string, handler http.Handler) http.Handler {
+	tracer := otel.Tracer("github.com/EkamSinghPandher/Tee-Google/vsock/tracecontext")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, ok := req.Context().Value(connKey{}).(*Conn)
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		ctx := conn.Context(req.Context())
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		ctx, span := tracer.Start(ctx, name+" "+req.Method+" "+req.URL.Path, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
@@ -0,0 +1,63 @@
+package tracecontext
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPreambleRoundTrip(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := NewConn(server)
+	defer conn.Close()
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	go func() {
+		Write(trace.ContextWithSpanContext(context.Background(), spanContext), client)
+		// Without a span nothing is sent
+		Write(context.Background(), client)
+		io.WriteString(client, "GET / HTTP/1.1\r\n")
+		client.Close()
+	}()
+
+	remote := trace.SpanContextFromContext(conn.Context(context.Background()))
+	if !remote.IsRemote() || remote.TraceID() != spanContext.TraceID() || remote.SpanID() != spanContext.SpanID() {
+		t.Fatalf("Expected remote span context %v, got %v", spanContext, remote)
+	}
+
+	payload, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	if string(payload) != "GET / HTTP/1.1\r\n" {
+		t.Fatalf("Expected only the payload, got %q", payload)
+	}
+}
+
+func TestConnWithoutPreamble(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := NewConn(server)
+	defer conn.Close()
+
+	go func() {
+		io.WriteString(client, "\x16\x03\x01")
+		client.Close()
+	}()
+
+	if trace.SpanContextFromContext(conn.Context(context.Background())).IsValid() {
+		t.Fatalf("A connection without preamble must not carry a trace context")
+	}
+	payload, err := io.ReadAll(conn)
+	if err != nil || string(payload) != "\x16\x03\x01" {
+		t.Fatalf("Expected the payload untouched, got %q: %v", payload, err)
+	}
+}